@@ -35,8 +35,14 @@ func main() {
 			},
 
 			broker.NewRabbitProducerService,
+			func(redis *redis.RedisService) broker.CacheProvider {
+				return redis
+			},
 
 			sender.NewSenderRegistry,
+			func(db *db.Postgres) sender.StorageProvider {
+				return db
+			},
 
 			consumer.NewConsumer,
 			func(db *db.Postgres) consumer.StorageProvider {
@@ -46,6 +52,11 @@ func main() {
 				return redis
 			},
 
+			consumer.NewDeadLetterConsumer,
+			func(db *db.Postgres) consumer.DeadLetterStorage {
+				return db
+			},
+
 			web.NewNotifyHandler,
 			func(db *db.Postgres) web.StorageProvider {
 				return db
@@ -53,13 +64,55 @@ func main() {
 			func(redis *redis.RedisService) web.CacheProvider {
 				return redis
 			},
+
+			web.NewDeadLetterHandler,
+			func(db *db.Postgres) web.DeadLetterStorage {
+				return db
+			},
+
+			web.NewScheduleHandler,
+			func(db *db.Postgres) web.ScheduleStorage {
+				return db
+			},
+
+			web.NewQuietHoursHandler,
+			func(db *db.Postgres) web.QuietHoursStorage {
+				return db
+			},
+
+			web.NewPushHandler,
+			func(db *db.Postgres) web.PushStorage {
+				return db
+			},
+			func(registry *sender.SenderRegistry) web.VAPIDKeyProvider {
+				return registry
+			},
+			func(registry *sender.SenderRegistry) web.PushEndpointValidator {
+				return registry
+			},
+
+			web.NewTelegramHandler,
+			func(db *db.Postgres) web.TelegramSubscriberStorage {
+				return db
+			},
+
+			web.NewContactHandler,
+			func(db *db.Postgres) web.ContactStorage {
+				return db
+			},
 		),
+		// OnStop выполняются в обратном порядке: сначала останавливаются консьюмеры, продюсер
+		// и HTTP-сервер, и только потом закрываются отправители, Redis и Postgres
 		fx.Invoke(
-			di.StartHTTPServer,
+			di.ClosePostgresOnStop,
 			di.LoadCacheOnStart,
+			di.CloseSendersOnStop,
+			di.StartHTTPServer,
+			di.StartDebugServer,
+			di.StartIdempotencyPurge,
 			di.StartRabitProducer,
 			di.StartRabbitConsumer,
-			di.ClosePostgresOnStop,
+			di.StartDeadLetterConsumer,
 		),
 	)
 
@@ -15,9 +15,56 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/notify": {
+        "/contacts": {
+            "get": {
+                "description": "Возвращает контакты, новые первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contacts"
+                ],
+                "summary": "List Contacts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Contacts",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.Contact"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
-                "description": "Создает новое уведомление (Email, Telegram) и сохраняет его в БД и Redis",
+                "description": "Создает контакт: адреса в каналах (email, telegram_chat_id, phone), каналы в порядке предпочтения,\nязык и часовой пояс. Уведомление с contact_id отправляется по этим предпочтениям",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,41 +72,41 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "contacts"
                 ],
-                "summary": "Create Notification",
+                "summary": "Create Contact",
                 "parameters": [
                     {
-                        "description": "Notification to create",
-                        "name": "notification",
+                        "description": "Contact to create",
+                        "name": "contact",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/app.NotificationRequest"
+                            "$ref": "#/definitions/web.ContactRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created notification",
+                        "description": "Created contact",
                         "schema": {
-                            "$ref": "#/definitions/app.Notification"
+                            "$ref": "#/definitions/app.Contact"
                         }
                     },
                     "400": {
-                        "description": "Invalid input data",
+                        "description": "Malformed JSON or missing required fields",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "422": {
+                        "description": "Field values failed validation",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
                     },
                     "503": {
-                        "description": "Service unavailable (DB or cache)",
+                        "description": "Service unavailable",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
@@ -67,9 +114,54 @@ const docTemplate = `{
                 }
             }
         },
-        "/notify/{id}": {
+        "/contacts/{id}": {
             "get": {
-                "description": "Получает уведомление по ID (из кэша или базы данных)",
+                "description": "Возвращает контакт по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "contacts"
+                ],
+                "summary": "Get Contact",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Contact ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Contact",
+                        "schema": {
+                            "$ref": "#/definitions/app.Contact"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid contact ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Contact not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет данные контакта целиком. Уже созданные уведомления контакта при отправке\nвыберут канал по новым предпочтениям",
                 "consumes": [
                     "application/json"
                 ],
@@ -77,39 +169,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "contacts"
                 ],
-                "summary": "Get Notification",
+                "summary": "Update Contact",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Notification ID",
+                        "description": "Contact ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New contact data",
+                        "name": "contact",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.ContactRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Notification object",
+                        "description": "Updated contact",
                         "schema": {
-                            "$ref": "#/definitions/app.Notification"
+                            "$ref": "#/definitions/app.Contact"
                         }
                     },
                     "400": {
-                        "description": "Invalid notification ID",
+                        "description": "Invalid contact ID, malformed JSON or missing required fields",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Notification not found",
+                        "description": "Contact not found",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "422": {
+                        "description": "Field values failed validation",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
@@ -123,21 +224,18 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Удаляет уведомление по ID из кэша и базы данных",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Удаляет контакт. Его неотправленные уведомления уйдут по адресу, выбранному при создании",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "notifications"
+                    "contacts"
                 ],
-                "summary": "Delete Notification",
+                "summary": "Delete Contact",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Notification ID",
+                        "description": "Contact ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -145,19 +243,19 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Notification deleted successfully",
+                        "description": "Contact deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid notification ID",
+                        "description": "Invalid contact ID",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Contact not found",
                         "schema": {
                             "$ref": "#/definitions/web.ErrorResponse"
                         }
@@ -170,102 +268,2083 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "app.ChannelType": {
-            "type": "string",
-            "enum": [
-                "email",
-                "telegram"
-            ],
-            "x-enum-varnames": [
-                "Email",
-                "Telegram"
-            ]
         },
-        "app.Notification": {
-            "type": "object",
-            "properties": {
-                "channel": {
-                    "$ref": "#/definitions/app.ChannelType"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "message": {
-                    "type": "string"
-                },
-                "recipient": {
-                    "type": "string"
-                },
-                "send_at": {
-                    "type": "string"
-                },
-                "status": {
-                    "description": "pending, sent, failed, canceled",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/app.StatusType"
+        "/dead-letters": {
+            "get": {
+                "description": "Возвращает сообщения из dead-letter очереди (битые и не доставленные после всех попыток)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dead-letters"
+                ],
+                "summary": "List Dead Letters",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letters",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.DeadLetter"
+                            }
                         }
-                    ]
-                },
-                "updated_at": {
-                    "type": "string"
+                    },
+                    "400": {
+                        "description": "Invalid pagination",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "app.NotificationRequest": {
-            "type": "object",
-            "required": [
-                "channel",
-                "message",
-                "recipient",
-                "send_at"
-            ],
-            "properties": {
-                "channel": {
-                    "type": "string",
-                    "enum": [
-                        "email",
-                        "telegram"
-                    ]
-                },
-                "message": {
-                    "type": "string"
-                },
-                "recipient": {
-                    "type": "string"
-                },
-                "send_at": {
-                    "type": "string"
+        "/dead-letters/{id}": {
+            "get": {
+                "description": "Возвращает сообщение из dead-letter очереди по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dead-letters"
+                ],
+                "summary": "Get Dead Letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dead letter",
+                        "schema": {
+                            "$ref": "#/definitions/app.DeadLetter"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dead letter ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "app.StatusType": {
-            "type": "string",
-            "enum": [
-                "pending",
-                "sent",
-                "failed",
-                "canceled"
-            ],
-            "x-enum-varnames": [
-                "Pending",
-                "Sent",
-                "Failed",
-                "Canceled"
-            ]
-        },
-        "web.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "invalid input data"
+        "/dead-letters/{id}/replay": {
+            "post": {
+                "description": "Возвращает уведомление из dead-letter очереди в основной поток: статус pending, счетчик попыток сброшен",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "dead-letters"
+                ],
+                "summary": "Replay Dead Letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Notification scheduled for delivery",
+                        "schema": {
+                            "$ref": "#/definitions/app.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid dead letter ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Dead letter not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Dead letter already replayed or notification is not failed",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Malformed message cannot be replayed",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify": {
+            "get": {
+                "description": "Возвращает страницу уведомлений с фильтрами и сортировкой.\nДля следующей страницы передайте next_cursor из ответа в параметр cursor, не меняя остальные параметры",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "List Notifications",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Statuses, comma-separated (pending,sent,...)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel (email, telegram, sms, webhook, slack, webpush)",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Exact recipient",
+                        "name": "recipient",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "send_at \u003e= (RFC3339)",
+                        "name": "send_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "send_at \u003c (RFC3339)",
+                        "name": "send_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at \u003e= (RFC3339)",
+                        "name": "created_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "created_at \u003c (RFC3339)",
+                        "name": "created_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Case-insensitive substring of the message",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort field: created_at (default), send_at, updated_at",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort order: desc (default), asc",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of notifications",
+                        "schema": {
+                            "$ref": "#/definitions/app.NotificationPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, sort or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает новое уведомление (email, telegram, sms, webhook, slack, webpush) и сохраняет его в БД и Redis.\nС ключом Idempotency-Key (заголовок или поле idempotency_key) повтор того же запроса\nв течение срока хранения ключа возвращает исходное уведомление вместо создания нового.\nС полем timezone send_at можно передать локальным временем в этом поясе (2030-03-01T09:00:00)\nДля email можно задать subject, html (HTML-версия письма) и attachments (файлы в base64)\nДля telegram можно задать parse_mode (MarkdownV2, HTML), buttons (inline-кнопки со ссылками) и silent\nВместо channel и recipient можно передать contact_id — канал выберется по предпочтениям контакта при отправке\nЗначения проверяются по правилам канала; ошибки возвращаются списком fields с именем поля и кодом",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Create Notification",
+                "parameters": [
+                    {
+                        "description": "Notification to create",
+                        "name": "notification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.NotificationRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client-generated key for safe retries",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created notification (or the original one on replay, with Idempotent-Replayed: true)",
+                        "schema": {
+                            "$ref": "#/definitions/app.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON or missing required fields",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Field values failed validation or idempotency key reused with a different request",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable (DB or cache)",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/batch": {
+            "post": {
+                "description": "Создает пакет уведомлений одной транзакцией. Каждый элемент проверяется отдельно:\nневалидные элементы пропускаются и попадают в results с ошибкой, валидные сохраняются.\nУведомления пакета не кладутся в Redis сразу — кэш заполнится при первом чтении",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Create Notifications Batch",
+                "parameters": [
+                    {
+                        "description": "Notifications to create (up to 10000)",
+                        "name": "notifications",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/web.NotificationRequest"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "At least one notification created",
+                        "schema": {
+                            "$ref": "#/definitions/web.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Body is not a JSON array or the batch is empty or too large",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "No valid notifications in the batch",
+                        "schema": {
+                            "$ref": "#/definitions/web.BatchResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}": {
+            "get": {
+                "description": "Получает уведомление по ID (из кэша или базы данных).\nПараметр fields ограничивает набор полей ответа, например fields=status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get Notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated list of fields to return, e.g. status",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification object",
+                        "schema": {
+                            "$ref": "#/definitions/app.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID or fields",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет уведомление по ID из кэша и базы данных",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Delete Notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Notification deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Изменяет время отправки, сообщение или получателя уведомления в статусе pending.\nТребует текущую версию в заголовке If-Match (значение ETag) или в поле version",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Update Notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Expected version (ETag)",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "changes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.NotificationUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated notification",
+                        "schema": {
+                            "$ref": "#/definitions/app.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification is not pending",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Version mismatch",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Field values failed validation",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "428": {
+                        "description": "Version is required",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/attempts": {
+            "get": {
+                "description": "Возвращает историю попыток отправки уведомления по всем каналам, включая запасные, в хронологическом порядке.\nКанал, через который уведомление доставлено, — в поле delivered_channel уведомления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Get Delivery Attempts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Delivery attempts",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.DeliveryAttempt"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/notify/{id}/cancel": {
+            "post": {
+                "description": "Отменяет уведомление, если оно еще не передано на отправку (pending, processing, queued). История сохраняется",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Cancel Notification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Canceled notification",
+                        "schema": {
+                            "$ref": "#/definitions/app.Notification"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Notification not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Notification is already being sent or finished",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/push/subscriptions": {
+            "get": {
+                "description": "Возвращает push-подписки получателя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "push"
+                ],
+                "summary": "List Push Subscriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.PushSubscription"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Recipient is required",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Сохраняет подписку браузера на Web Push для получателя канала webpush.\nПовторная регистрация того же endpoint обновляет ключи и получателя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "push"
+                ],
+                "summary": "Register Push Subscription",
+                "parameters": [
+                    {
+                        "description": "Push subscription",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.PushSubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Saved subscription",
+                        "schema": {
+                            "$ref": "#/definitions/app.PushSubscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid subscription",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/push/subscriptions/{id}": {
+            "delete": {
+                "description": "Удаляет push-подписку (например, после pushSubscription.unsubscribe() в браузере)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "push"
+                ],
+                "summary": "Delete Push Subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Subscription deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid subscription ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscription not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/push/vapid-public-key": {
+            "get": {
+                "description": "Возвращает открытый ключ VAPID, который браузер передает в pushManager.subscribe()",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "push"
+                ],
+                "summary": "Get VAPID Public Key",
+                "responses": {
+                    "200": {
+                        "description": "VAPID public key",
+                        "schema": {
+                            "$ref": "#/definitions/web.VAPIDPublicKeyResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Web Push is not configured",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/quiet-hours": {
+            "get": {
+                "description": "Возвращает тихие часы получателя по всем каналам",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiet-hours"
+                ],
+                "summary": "List Quiet Hours",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quiet hours",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.QuietHours"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Recipient is required",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Задает тихие часы получателя (например, 22:00–08:00 в его часовом поясе).\nУведомление, пришедшее на отправку в тихие часы, откладывается до их окончания без траты попытки",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiet-hours"
+                ],
+                "summary": "Set Quiet Hours",
+                "parameters": [
+                    {
+                        "description": "Quiet hours",
+                        "name": "quiet_hours",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.QuietHoursRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved quiet hours",
+                        "schema": {
+                            "$ref": "#/definitions/app.QuietHours"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time or timezone",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет тихие часы получателя для канала (без channel — общие для всех каналов)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "quiet-hours"
+                ],
+                "summary": "Delete Quiet Hours",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel",
+                        "name": "channel",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Quiet hours deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Recipient is required",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Quiet hours not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules": {
+            "get": {
+                "description": "Возвращает расписания, новые первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "List Schedules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 500)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Schedules",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/app.Schedule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid pagination",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает расписание повторяющихся уведомлений (cron или RRULE с часовым поясом).\nПродюсер заранее создает из него обычные уведомления на каждое срабатывание",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Create Schedule",
+                "parameters": [
+                    {
+                        "description": "Schedule to create",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/web.ScheduleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created schedule",
+                        "schema": {
+                            "$ref": "#/definitions/app.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed JSON or missing required fields",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Invalid expression, timezone or bounds",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}": {
+            "get": {
+                "description": "Возвращает расписание по ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Get Schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Schedule",
+                        "schema": {
+                            "$ref": "#/definitions/app.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет расписание и отменяет созданные им уведомления, которые еще не отправлены",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Delete Schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Schedule deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/pause": {
+            "post": {
+                "description": "Приостанавливает расписание и отменяет уже созданные, но еще не отправленные уведомления",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Pause Schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paused schedule",
+                        "schema": {
+                            "$ref": "#/definitions/app.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Schedule is not active",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/schedules/{id}/resume": {
+            "post": {
+                "description": "Возобновляет расписание с ближайшего будущего срабатывания; пропущенные за паузу срабатывания не отправляются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedules"
+                ],
+                "summary": "Resume Schedule",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Schedule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Resumed schedule",
+                        "schema": {
+                            "$ref": "#/definitions/app.Schedule"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Schedule is not paused",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/telegram/subscribers/{username}": {
+            "get": {
+                "description": "Ищет подписчика бота по username (с @ или без, регистр не важен) и возвращает его chat_id.\nПодписчик появляется после команды /start боту и удаляется после /stop или блокировки бота",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "telegram"
+                ],
+                "summary": "Get Telegram Subscriber",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Telegram username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscriber",
+                        "schema": {
+                            "$ref": "#/definitions/app.TelegramSubscriber"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid username",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Subscriber not found",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/web.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "app.Attachment": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "format": "base64"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                }
+            }
+        },
+        "app.Button": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "example": "Открыть заказ"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://example.com/orders/42"
+                }
+            }
+        },
+        "app.ChannelType": {
+            "type": "string",
+            "enum": [
+                "email",
+                "telegram",
+                "sms"
+            ],
+            "x-enum-varnames": [
+                "Email",
+                "Telegram",
+                "SMS"
+            ]
+        },
+        "app.Contact": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "description": "Channels — каналы в порядке предпочтения; по умолчанию все каналы, для которых есть адрес",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.ChannelType"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "тег BCP 47, например ru-RU",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "E.164",
+                    "type": "string"
+                },
+                "telegram_chat_id": {
+                    "description": "chat ID или @username",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA; в нем трактуется send_at без смещения",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.DeadLetter": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "death_count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notification_id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "replayed_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.DeliveryAttempt": {
+            "type": "object",
+            "properties": {
+                "attempt": {
+                    "description": "номер попытки в этом канале, с 1",
+                    "type": "integer"
+                },
+                "channel": {
+                    "$ref": "#/definitions/app.ChannelType"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fallback_step": {
+                    "description": "0 — основной канал",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "notification_id": {
+                    "type": "string"
+                },
+                "outcome": {
+                    "$ref": "#/definitions/app.DeliveryOutcome"
+                },
+                "recipient": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.DeliveryOutcome": {
+            "type": "string",
+            "enum": [
+                "sent",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "AttemptSent",
+                "AttemptFailed"
+            ]
+        },
+        "app.Destination": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/app.ChannelType"
+                },
+                "recipient": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "in_past"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "send_at"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "send_at is in the past"
+                }
+            }
+        },
+        "app.Notification": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.Attachment"
+                    }
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "buttons": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/app.Button"
+                        }
+                    }
+                },
+                "channel": {
+                    "$ref": "#/definitions/app.ChannelType"
+                },
+                "contact_id": {
+                    "description": "контакт, по предпочтениям которого выбирается канал",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "delivered_channel": {
+                    "description": "канал, через который уведомление доставлено",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/app.ChannelType"
+                        }
+                    ]
+                },
+                "fallback_step": {
+                    "type": "integer"
+                },
+                "fallbacks": {
+                    "description": "Fallbacks — запасные каналы по порядку (см. SetFallbacks); FallbackStep — сколько из них уже\nзадействовано: 0 — доставка идет по основному каналу, иначе Channel и Recipient взяты из Fallbacks[FallbackStep-1],\nа основной канал сохранен в Primary",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.Destination"
+                    }
+                },
+                "html": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "parse_mode": {
+                    "type": "string"
+                },
+                "primary": {
+                    "$ref": "#/definitions/app.Destination"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "description": "расписание, породившее уведомление",
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "silent": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "pending, processing, queued, sending, sent, failed, canceled",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/app.StatusType"
+                        }
+                    ]
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "app.NotificationPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.Notification"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.PushSubscription": {
+            "type": "object",
+            "properties": {
+                "auth": {
+                    "description": "секрет аутентификации, base64url",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "p256dh": {
+                    "description": "открытый ключ браузера, base64url",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.QuietHours": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/app.ChannelType"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end": {
+                    "description": "ЧЧ:ММ, не включительно",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "start": {
+                    "description": "ЧЧ:ММ",
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.Schedule": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/app.ChannelType"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "expression": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "$ref": "#/definitions/app.ScheduleKind"
+                },
+                "max_occurrences": {
+                    "description": "0 — без ограничения",
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "occurrences": {
+                    "description": "сколько уведомлений уже порождено",
+                    "type": "integer"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/app.ScheduleStatus"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "app.ScheduleKind": {
+            "type": "string",
+            "enum": [
+                "cron",
+                "rrule"
+            ],
+            "x-enum-varnames": [
+                "ScheduleCron",
+                "ScheduleRRule"
+            ]
+        },
+        "app.ScheduleStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "paused",
+                "completed"
+            ],
+            "x-enum-varnames": [
+                "ScheduleActive",
+                "SchedulePaused",
+                "ScheduleCompleted"
+            ]
+        },
+        "app.StatusType": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "processing",
+                "queued",
+                "sending",
+                "sent",
+                "failed",
+                "canceled"
+            ],
+            "x-enum-varnames": [
+                "Pending",
+                "Processing",
+                "Queued",
+                "Sending",
+                "Sent",
+                "Failed",
+                "Canceled"
+            ]
+        },
+        "app.TelegramSubscriber": {
+            "type": "object",
+            "properties": {
+                "chat_id": {
+                    "type": "integer"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "subscribed_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.FieldError"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/web.BatchItemResult"
+                    }
+                }
+            }
+        },
+        "web.ContactRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.ChannelType"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "telegram_chat_id": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "invalid input data"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.FieldError"
+                    }
+                }
+            }
+        },
+        "web.NotificationRequest": {
+            "type": "object",
+            "required": [
+                "message",
+                "send_at"
+            ],
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.Attachment"
+                    }
+                },
+                "buttons": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/app.Button"
+                        }
+                    }
+                },
+                "channel": {
+                    "type": "string"
+                },
+                "contact_id": {
+                    "type": "string"
+                },
+                "fallbacks": {
+                    "description": "у уведомления контакта запасными становятся остальные его каналы",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/app.Destination"
+                    }
+                },
+                "html": {
+                    "type": "string"
+                },
+                "idempotency_key": {
+                    "description": "IdempotencyKey — альтернатива заголовку Idempotency-Key",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "parse_mode": {
+                    "description": "ParseMode (MarkdownV2, HTML), Buttons (ряды кнопок-ссылок) и Silent поддерживает telegram",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "silent": {
+                    "type": "boolean"
+                },
+                "subject": {
+                    "description": "Subject, HTML и Attachments поддерживает только email; message остается текстовой версией письма",
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.NotificationUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "send_at": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "web.PushSubscriptionRequest": {
+            "type": "object",
+            "required": [
+                "endpoint",
+                "recipient"
+            ],
+            "properties": {
+                "endpoint": {
+                    "type": "string"
+                },
+                "keys": {
+                    "type": "object",
+                    "required": [
+                        "auth",
+                        "p256dh"
+                    ],
+                    "properties": {
+                        "auth": {
+                            "type": "string"
+                        },
+                        "p256dh": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "recipient": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.QuietHoursRequest": {
+            "type": "object",
+            "required": [
+                "end",
+                "recipient",
+                "start"
+            ],
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.ScheduleRequest": {
+            "type": "object",
+            "required": [
+                "channel",
+                "expression",
+                "kind",
+                "message",
+                "recipient"
+            ],
+            "properties": {
+                "channel": {
+                    "type": "string"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "expression": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "cron",
+                        "rrule"
+                    ]
+                },
+                "max_occurrences": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "message": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                }
+            }
+        },
+        "web.VAPIDPublicKeyResponse": {
+            "type": "object",
+            "properties": {
+                "public_key": {
+                    "type": "string"
                 }
             }
         }
@@ -274,12 +2353,12 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "",
+	Version:          "1.0",
 	Host:             "",
-	BasePath:         "",
+	BasePath:         "/",
 	Schemes:          []string{},
-	Title:            "",
-	Description:      "",
+	Title:            "DelayedNotifier API",
+	Description:      "API для сервиса отложенных уведомлений",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
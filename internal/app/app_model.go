@@ -1,6 +1,8 @@
 package app
 
 import (
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"time"
@@ -8,66 +10,195 @@ import (
 
 type StatusType string
 
+// Жизненный цикл уведомления:
+//
+//	pending → processing → queued → sending → sent | failed
+//	                ↘ pending (публикация не удалась, аренда истекла)
+//	sending → pending (повторная попытка), failed → pending (replay из dead-letter)
+//	pending | processing | queued → canceled
 const (
-	Pending  StatusType = "pending"
-	Sent     StatusType = "sent"
-	Failed   StatusType = "failed"
-	Canceled StatusType = "canceled"
+	Pending    StatusType = "pending"
+	Processing StatusType = "processing"
+	Queued     StatusType = "queued"
+	Sending    StatusType = "sending"
+	Sent       StatusType = "sent"
+	Failed     StatusType = "failed"
+	Canceled   StatusType = "canceled"
 )
 
+var (
+	// ErrInvalidTransition — переход между статусами запрещен таблицей переходов.
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrStatusConflict — статус уведомления в БД не совпал с ожидаемым (его изменил кто-то другой).
+	ErrStatusConflict = errors.New("notification status conflict")
+	// ErrVersionConflict — уведомление изменено с момента, когда клиент получил его версию.
+	ErrVersionConflict = errors.New("notification version conflict")
+)
+
+var transitions = map[StatusType][]StatusType{
+	Pending: {Processing, Canceled},
+	// консьюмер может получить сообщение раньше, чем продюсер отметит строку как queued
+	Processing: {Queued, Pending, Sending, Canceled},
+	Queued:     {Sending, Canceled},
+	Sending:    {Sent, Failed, Pending},
+	Failed:     {Pending},
+	Sent:       {},
+	Canceled:   {},
+}
+
+// CanTransition сообщает, разрешен ли переход из статуса from в статус to.
+func CanTransition(from, to StatusType) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition возвращает ErrInvalidTransition, если переход запрещен.
+func ValidateTransition(from, to StatusType) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
+
+// IsTerminal сообщает, что из статуса больше нет переходов.
+func (s StatusType) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// IsValid сообщает, что статус входит в жизненный цикл уведомления.
+func (s StatusType) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
 type ChannelType string
 
 const (
 	Email    ChannelType = "email"
 	Telegram ChannelType = "telegram"
+	SMS      ChannelType = "sms"
 )
 
 type Notification struct {
-	ID        uuid.UUID   `db:"id" json:"id"`
-	Channel   ChannelType `db:"channel" json:"channel"`
-	Recipient string      `db:"recipient" json:"recipient"`
-	Message   string      `db:"message" json:"message"`
-	SendAt    time.Time   `db:"send_at" json:"send_at"`
-	Status    StatusType  `db:"status" json:"status"` // pending, sent, failed, canceled
-	CreatedAt time.Time   `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
-}
-
-func NewNotification(Channel, Message, Recipient, SendAt string) (*Notification, error) {
-	sendAt, error := time.Parse(time.RFC3339, SendAt)
-
-	if error != nil {
-		wbzlog.Logger.Error().Err(error).Msg("Failed to parse send_at time, defaulting to now")
-		sendAt = time.Now()
+	ID            uuid.UUID    `db:"id" json:"id"`
+	Channel       ChannelType  `db:"channel" json:"channel"`
+	Recipient     string       `db:"recipient" json:"recipient"`
+	Message       string       `db:"message" json:"message"`
+	Subject       string       `db:"subject" json:"subject,omitempty"`
+	HTML          string       `db:"html" json:"html,omitempty"`
+	Attachments   []Attachment `db:"attachments" json:"attachments,omitempty"`
+	ParseMode     string       `db:"parse_mode" json:"parse_mode,omitempty"`
+	Buttons       [][]Button   `db:"buttons" json:"buttons,omitempty"`
+	Silent        bool         `db:"silent" json:"silent,omitempty"`
+	SendAt        time.Time    `db:"send_at" json:"send_at"`
+	Status        StatusType   `db:"status" json:"status"` // pending, processing, queued, sending, sent, failed, canceled
+	Attempts      int          `db:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time   `db:"next_attempt_at" json:"next_attempt_at,omitempty"`
+	LastError     string       `db:"last_error" json:"last_error,omitempty"`
+	Version       int          `db:"version" json:"version"`
+	ScheduleID    *uuid.UUID   `db:"schedule_id" json:"schedule_id,omitempty"` // расписание, породившее уведомление
+	ContactID     *uuid.UUID   `db:"contact_id" json:"contact_id,omitempty"`   // контакт, по предпочтениям которого выбирается канал
+	// Fallbacks — запасные каналы по порядку (см. SetFallbacks); FallbackStep — сколько из них уже
+	// задействовано: 0 — доставка идет по основному каналу, иначе Channel и Recipient взяты из Fallbacks[FallbackStep-1],
+	// а основной канал сохранен в Primary
+	Fallbacks        []Destination `db:"fallbacks" json:"fallbacks,omitempty"`
+	FallbackStep     int           `db:"fallback_step" json:"fallback_step,omitempty"`
+	Primary          *Destination  `db:"primary_destination" json:"primary,omitempty"`
+	DeliveredChannel ChannelType   `db:"delivered_channel" json:"delivered_channel,omitempty"` // канал, через который уведомление доставлено
+	CreatedAt        time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time     `db:"updated_at" json:"updated_at"`
+}
+
+// NewNotification проверяет входные данные и создает уведомление в pending. send_at — RFC3339
+// или локальное время в поясе timezone (см. ParseSendAt). Все найденные ошибки возвращаются
+// одной ValidationErrors.
+func NewNotification(channel, message, recipient, sendAt, timezone string) (*Notification, error) {
+	return NewRichNotification(channel, message, recipient, sendAt, timezone, RichContent{})
+}
+
+// NewRichNotification — NewNotification с необязательными частями, которые поддерживают
+// не все каналы (см. RichContent).
+func NewRichNotification(channel, message, recipient, sendAt, timezone string, content RichContent) (*Notification, error) {
+	now := time.Now()
+	errs := validateContent(nil, ChannelType(channel), &message, &recipient)
+	errs = validateRichContent(errs, ChannelType(channel), &content)
+	at, errs := validateSendAt(errs, sendAt, timezone, now)
+	if len(errs) > 0 {
+		return nil, errs
 	}
-	if Channel != "email" && Channel != "telegram" {
-		wbzlog.Logger.Error().Str("channel", Channel).Msg("Invalid channel type, defaulting to email")
-		Channel = "email"
+
+	wbzlog.Logger.Debug().Str("channel", channel).Msg("Creating new notification")
+	return &Notification{
+		ID:          uuid.New(),
+		Channel:     ChannelType(channel),
+		Message:     message,
+		Subject:     content.Subject,
+		HTML:        content.HTML,
+		Attachments: content.Attachments,
+		ParseMode:   content.ParseMode,
+		Buttons:     content.Buttons,
+		Silent:      content.Silent,
+		Recipient:   recipient,
+		SendAt:      at,
+		Status:      Pending,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// NotificationPatch — изменения ожидающего уведомления; nil означает "поле не меняется".
+type NotificationPatch struct {
+	Message   *string
+	Recipient *string
+	SendAt    *string
+	// Timezone позволяет передать SendAt как локальное время в этом поясе (см. ParseSendAt)
+	Timezone string
+}
+
+// ApplyPatch применяет изменения к уведомлению, которое еще находится в pending.
+// Новые значения проверяются по правилам канала уведомления, ошибки возвращаются как ValidationErrors.
+func (n *Notification) ApplyPatch(patch NotificationPatch) error {
+	if n.Status != Pending {
+		return fmt.Errorf("%w: only pending notifications can be updated, current status %s", ErrStatusConflict, n.Status)
 	}
 
-	if messageLen := len(Message); messageLen == 0 || messageLen > 1000 {
-		wbzlog.Logger.Error().Int("message_length", messageLen).Msg("Invalid message length, defaulting to 'No message provided'")
-		Message = "No message provided"
+	now := time.Now()
+	errs := validateContent(nil, n.Channel, patch.Message, patch.Recipient)
+	if n.ContactID != nil && patch.Recipient != nil {
+		errs = append(errs, newFieldError("recipient", ErrInvalidRecipient, "recipient of a contact notification follows the contact, update the contact instead"))
+	}
+	var sendAt time.Time
+	if patch.SendAt != nil {
+		sendAt, errs = validateSendAt(errs, *patch.SendAt, patch.Timezone, now)
+	}
+	if len(errs) > 0 {
+		return errs
 	}
 
-	channel := ChannelType(Channel)
+	if patch.SendAt != nil {
+		n.SendAt = sendAt
+		// новое время отправки отменяет отложенную повторную попытку
+		n.NextAttemptAt = nil
+	}
+	if patch.Message != nil {
+		n.Message = *patch.Message
+	}
+	if patch.Recipient != nil {
+		n.Recipient = *patch.Recipient
+	}
 
-	// Log the channel type for debugging
-	wbzlog.Logger.Debug().Str("channel", string(channel)).Msg("Creating new notification")
-	return &Notification{
-		ID:        uuid.New(),
-		Channel:   channel,
-		Message:   Message,
-		Recipient: Recipient,
-		SendAt:    sendAt,
-		Status:    Pending,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}, nil
+	n.UpdatedAt = now
+	return nil
 }
 
 func (n *Notification) MarkAsSent() {
 	n.Status = Sent
+	n.DeliveredChannel = n.Channel
 	n.UpdatedAt = time.Now()
 }
 
@@ -76,6 +207,21 @@ func (n *Notification) MarkAsFailed() {
 	n.UpdatedAt = time.Now()
 }
 
+// MarkForRetry возвращает уведомление в pending до момента следующей попытки отправки.
+func (n *Notification) MarkForRetry(nextAttemptAt time.Time, reason string) {
+	n.Status = Pending
+	n.NextAttemptAt = &nextAttemptAt
+	n.LastError = reason
+	n.UpdatedAt = time.Now()
+}
+
+// DeferUntil откладывает доставку без траты попытки, например до конца тихих часов получателя.
+func (n *Notification) DeferUntil(until time.Time) {
+	n.Status = Pending
+	n.NextAttemptAt = &until
+	n.UpdatedAt = time.Now()
+}
+
 func (n *Notification) MarkAsCanceled() {
 	n.Status = Canceled
 	n.UpdatedAt = time.Now()
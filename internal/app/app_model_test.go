@@ -1,14 +1,16 @@
 package app
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 	"time"
 )
 
 func TestNewNotificationValidInput(t *testing.T) {
 
-	notification, err := NewNotification("email", "Hello, world!", "test@example.com", time.Now().UTC().Format(time.RFC3339))
+	notification, err := NewNotification("email", "Hello, world!", "test@example.com", time.Now().UTC().Format(time.RFC3339), "")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, notification)
@@ -21,33 +23,74 @@ func TestNewNotificationValidInput(t *testing.T) {
 }
 
 func TestNewNotificationInvalidSendAt(t *testing.T) {
-	n, err := NewNotification("email", "Invalid time test", "test@example.com", "not-a-time")
+	n, err := NewNotification("email", "Invalid time test", "test@example.com", "not-a-time", "")
+
+	assert.Nil(t, n)
+	assert.ErrorIs(t, err, ErrInvalidSendAt)
+}
+
+func TestNewNotificationSendAtInPast(t *testing.T) {
+	_, err := NewNotification("email", "Late", "test@example.com", time.Now().Add(-time.Hour).Format(time.RFC3339), "")
+	assert.ErrorIs(t, err, ErrSendAtInPast)
+
+	// небольшое отставание часов клиента допустимо
+	_, err = NewNotification("email", "Now", "test@example.com", time.Now().Add(-time.Minute).Format(time.RFC3339), "")
 	assert.NoError(t, err)
-	assert.WithinDuration(t, time.Now(), n.SendAt, time.Second*2, "Should default to now when send_at invalid")
 }
 
 func TestNewNotificationInvalidChannel(t *testing.T) {
+	_, err := NewNotification("whatsapp", "Test message", "test@example.com", time.Now().Format(time.RFC3339), "")
 
-	n, err := NewNotification("whatsapp", "Test message", "test@example.com", time.Now().Format(time.RFC3339))
-	assert.NoError(t, err)
-	assert.Equal(t, Email, n.Channel, "Should default to email when channel invalid")
+	assert.ErrorIs(t, err, ErrUnknownChannel)
 }
 
 func TestNewNotificationInvalidMessageLength(t *testing.T) {
-	n, err := NewNotification("email", "", "test@example.com", time.Now().Format(time.RFC3339))
-	assert.NoError(t, err)
-	assert.Equal(t, "No message provided", n.Message)
+	_, err := NewNotification("email", "", "test@example.com", time.Now().Format(time.RFC3339), "")
+
+	assert.ErrorIs(t, err, ErrEmptyMessage)
 }
 
 func TestNewNotificationLongMessage(t *testing.T) {
-	longMsg := make([]byte, 1001)
-	for i := range longMsg {
-		longMsg[i] = 'a'
-	}
+	// для telegram предел 4096 символов, для email — больше
+	longMsg := strings.Repeat("ы", 4097)
+
+	_, err := NewNotification("telegram", longMsg, "123", time.Now().Format(time.RFC3339), "")
+	assert.ErrorIs(t, err, ErrMessageTooLong)
 
-	n, err := NewNotification("email", string(longMsg), "test@example.com", time.Now().Format(time.RFC3339))
+	_, err = NewNotification("email", longMsg, "test@example.com", time.Now().Format(time.RFC3339), "")
 	assert.NoError(t, err)
-	assert.Equal(t, "No message provided", n.Message)
+}
+
+func TestNewNotificationInvalidRecipient(t *testing.T) {
+	_, err := NewNotification("email", "Hi", "not an email", time.Now().Format(time.RFC3339), "")
+	assert.ErrorIs(t, err, ErrInvalidRecipient)
+
+	_, err = NewNotification("telegram", "Hi", "someone", time.Now().Format(time.RFC3339), "")
+	assert.ErrorIs(t, err, ErrInvalidRecipient)
+
+	_, err = NewNotification("telegram", "Hi", "-1001234567890", time.Now().Format(time.RFC3339), "")
+	assert.NoError(t, err)
+}
+
+func TestNewNotificationReportsAllFields(t *testing.T) {
+	_, err := NewNotification("email", "", "nobody", "yesterday", "")
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"message", "recipient", "send_at"}, fields)
+	assert.Equal(t, "invalid_recipient", errs[1].Code)
+}
+
+func TestNewNotificationUnknownTimezone(t *testing.T) {
+	_, err := NewNotification("email", "Hi", "test@example.com", "2030-03-01T09:00:00", "Mars/Olympus")
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, "timezone", errs[0].Field)
 }
 
 func TestMarkAsSent(t *testing.T) {
@@ -78,3 +121,61 @@ func TestIsValidUUID(t *testing.T) {
 	assert.True(t, IsValidUUID(valid))
 	assert.False(t, IsValidUUID(invalid))
 }
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, CanTransition(Pending, Processing))
+	assert.True(t, CanTransition(Queued, Sending))
+	assert.True(t, CanTransition(Sending, Pending))
+	assert.True(t, CanTransition(Queued, Canceled))
+
+	assert.False(t, CanTransition(Canceled, Sent))
+	assert.False(t, CanTransition(Sending, Canceled))
+	assert.False(t, CanTransition(Sent, Pending))
+}
+
+func TestValidateTransition(t *testing.T) {
+	assert.NoError(t, ValidateTransition(Sending, Sent))
+	assert.ErrorIs(t, ValidateTransition(Canceled, Sent), ErrInvalidTransition)
+}
+
+func TestTerminalStatuses(t *testing.T) {
+	assert.True(t, Sent.IsTerminal())
+	assert.True(t, Canceled.IsTerminal())
+	assert.False(t, Failed.IsTerminal())
+	assert.False(t, Pending.IsTerminal())
+}
+
+func TestApplyPatch(t *testing.T) {
+	n, _ := NewNotification("email", "Hello", "test@example.com", "2030-01-01T10:00:00Z", "")
+	message := "Updated"
+	sendAt := "2030-01-02T10:00:00Z"
+
+	err := n.ApplyPatch(NotificationPatch{Message: &message, SendAt: &sendAt})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated", n.Message)
+	assert.Equal(t, "test@example.com", n.Recipient)
+	assert.Equal(t, 2, n.SendAt.Day())
+}
+
+func TestApplyPatchRejectsNotPending(t *testing.T) {
+	n := &Notification{Status: Queued}
+	message := "Updated"
+
+	assert.ErrorIs(t, n.ApplyPatch(NotificationPatch{Message: &message}), ErrStatusConflict)
+}
+
+func TestApplyPatchInvalidSendAt(t *testing.T) {
+	n := &Notification{Status: Pending, Channel: Email}
+	sendAt := "tomorrow"
+
+	assert.ErrorIs(t, n.ApplyPatch(NotificationPatch{SendAt: &sendAt}), ErrInvalidSendAt)
+}
+
+func TestApplyPatchValidatesRecipientForChannel(t *testing.T) {
+	n := &Notification{Status: Pending, Channel: Telegram, Recipient: "123"}
+	recipient := "user@example.com"
+
+	assert.ErrorIs(t, n.ApplyPatch(NotificationPatch{Recipient: &recipient}), ErrInvalidRecipient)
+	assert.Equal(t, "123", n.Recipient)
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"fmt"
+	"net/mail"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// ChannelSpec — правила канала доставки, по которым проверяются уведомления.
+// Каналы регистрируются пакетом sender вместе с реализацией отправки.
+type ChannelSpec struct {
+	Name             ChannelType
+	MaxMessageLength int // в символах
+	// ValidRecipient проверяет формат получателя и возвращает понятную пользователю ошибку
+	ValidRecipient func(recipient string) error
+	// ValidMessage — необязательная проверка текста сверх MaxMessageLength (например, числа
+	// SMS-сегментов); ошибка возвращается клиенту как слишком длинное сообщение
+	ValidMessage func(message string) error
+	// RichContent — канал умеет тему, HTML-версию и вложения (RichContent уведомления)
+	RichContent bool
+	// ParseModes — поддерживаемые режимы разметки текста; MaxButtons — предел кнопок под сообщением
+	// (0 — кнопки не поддерживаются); SilentDelivery — доставка без звука
+	ParseModes     []string
+	MaxButtons     int
+	SilentDelivery bool
+}
+
+var (
+	channelsMu sync.RWMutex
+	channels   = map[ChannelType]ChannelSpec{}
+)
+
+// RegisterChannel добавляет канал доставки. Повторная регистрация имени — ошибка программиста.
+func RegisterChannel(spec ChannelSpec) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+
+	if spec.Name == "" || spec.MaxMessageLength <= 0 || spec.ValidRecipient == nil {
+		panic(fmt.Sprintf("app: incomplete channel spec %q", spec.Name))
+	}
+	if _, ok := channels[spec.Name]; ok {
+		panic(fmt.Sprintf("app: channel %q registered twice", spec.Name))
+	}
+	channels[spec.Name] = spec
+}
+
+// UpdateChannel меняет правила зарегистрированного канала, когда они зависят от настроек
+// отправителя (политика хостов вебхуков, предел SMS-сегментов). Вызывается реестром отправителей.
+func UpdateChannel(name ChannelType, update func(spec *ChannelSpec)) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+
+	spec, ok := channels[name]
+	if !ok {
+		panic(fmt.Sprintf("app: channel %q is not registered", name))
+	}
+	update(&spec)
+	if spec.Name != name || spec.MaxMessageLength <= 0 || spec.ValidRecipient == nil {
+		panic(fmt.Sprintf("app: incomplete channel spec %q", name))
+	}
+	channels[name] = spec
+}
+
+// checkMessage проверяет длину непустого текста и дополнительные правила канала.
+func (s ChannelSpec) checkMessage(message string) error {
+	if length := utf8.RuneCountInString(message); length > s.MaxMessageLength {
+		return fmt.Errorf("message is %d characters, %s allows at most %d", length, s.Name, s.MaxMessageLength)
+	}
+	if s.ValidMessage != nil {
+		return s.ValidMessage(message)
+	}
+	return nil
+}
+
+// LookupChannel возвращает правила зарегистрированного канала.
+func LookupChannel(name ChannelType) (ChannelSpec, bool) {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+
+	spec, ok := channels[name]
+	return spec, ok
+}
+
+// RegisteredChannels возвращает имена зарегистрированных каналов по алфавиту.
+func RegisteredChannels() []ChannelType {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+
+	names := make([]ChannelType, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// ValidEmail принимает только голый адрес, без отображаемого имени.
+func ValidEmail(recipient string) error {
+	addr, err := mail.ParseAddress(recipient)
+	if err != nil || addr.Address != recipient {
+		return fmt.Errorf("%q is not a valid email address", recipient)
+	}
+	return nil
+}
+
+// ValidChatID принимает числовой идентификатор чата Telegram; у групп и каналов он отрицательный.
+func ValidChatID(recipient string) error {
+	if _, err := strconv.ParseInt(recipient, 10, 64); err != nil {
+		return fmt.Errorf("%q is not a numeric telegram chat id", recipient)
+	}
+	return nil
+}
+
+// ValidTelegramRecipient принимает числовой chat ID или @username подписчика бота.
+func ValidTelegramRecipient(recipient string) error {
+	if _, ok := ParseTelegramUsername(recipient); ok {
+		return nil
+	}
+	if _, err := strconv.ParseInt(recipient, 10, 64); err != nil {
+		return fmt.Errorf("%q is neither a numeric telegram chat id nor an @username", recipient)
+	}
+	return nil
+}
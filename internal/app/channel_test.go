@@ -0,0 +1,38 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain регистрирует каналы так же, как это делает пакет sender в рабочем приложении.
+func TestMain(m *testing.M) {
+	RegisterChannel(ChannelSpec{Name: Email, MaxMessageLength: 10000, ValidRecipient: ValidEmail, RichContent: true})
+	RegisterChannel(ChannelSpec{Name: Telegram, MaxMessageLength: 4096, ValidRecipient: ValidTelegramRecipient,
+		ParseModes: []string{"MarkdownV2", "HTML"}, MaxButtons: 100, SilentDelivery: true})
+	os.Exit(m.Run())
+}
+
+func TestRegisteredChannels(t *testing.T) {
+	assert.Equal(t, []ChannelType{Email, Telegram}, RegisteredChannels())
+
+	_, ok := LookupChannel("pigeon")
+	assert.False(t, ok)
+}
+
+func TestRegisterChannelTwicePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterChannel(ChannelSpec{Name: Email, MaxMessageLength: 1, ValidRecipient: ValidEmail})
+	})
+}
+
+func TestValidTelegramRecipient(t *testing.T) {
+	for _, ok := range []string{"123456789", "-1001234567890", "@alice_smith", "@Bob12"} {
+		assert.NoError(t, ValidTelegramRecipient(ok), ok)
+	}
+	for _, bad := range []string{"", "alice_smith", "@bob", "@1alice", "@alice-smith", "@" + strings.Repeat("a", 33)} {
+		assert.Error(t, ValidTelegramRecipient(bad), bad)
+	}
+}
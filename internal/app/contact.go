@@ -0,0 +1,196 @@
+package app
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxContactNameLength — предельная длина имени контакта в символах.
+const MaxContactNameLength = 255
+
+// ContactDetails — данные контакта, которые задает клиент: адреса в каналах, порядок
+// предпочтения каналов, язык и часовой пояс.
+type ContactDetails struct {
+	Name           string `db:"name" json:"name"`
+	Email          string `db:"email" json:"email,omitempty"`
+	TelegramChatID string `db:"telegram_chat_id" json:"telegram_chat_id,omitempty"` // chat ID или @username
+	Phone          string `db:"phone" json:"phone,omitempty"`                       // E.164
+	// Channels — каналы в порядке предпочтения; по умолчанию все каналы, для которых есть адрес
+	Channels []ChannelType `db:"channels" json:"channels"`
+	Locale   string        `db:"locale" json:"locale,omitempty"`     // тег BCP 47, например ru-RU
+	Timezone string        `db:"timezone" json:"timezone,omitempty"` // IANA; в нем трактуется send_at без смещения
+}
+
+// Contact — получатель из справочника. Уведомление с contact_id адресуется контакту,
+// а канал и адрес выбираются по его предпочтениям в момент отправки.
+type Contact struct {
+	ID uuid.UUID `db:"id" json:"id"`
+	ContactDetails
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// contactChannels — каналы, адреса которых хранит контакт, в порядке по умолчанию.
+var contactChannels = []ChannelType{Email, Telegram, SMS}
+
+// localeTag — упрощенная форма тега BCP 47: язык и необязательные подтеги.
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+func NewContact(details ContactDetails) (*Contact, error) {
+	now := time.Now()
+	c := &Contact{ID: uuid.New(), CreatedAt: now}
+	if err := c.Update(details); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update проверяет и целиком заменяет данные контакта. Все найденные ошибки возвращаются
+// одной ValidationErrors.
+func (c *Contact) Update(details ContactDetails) error {
+	details.Name = strings.TrimSpace(details.Name)
+	errs := ValidationErrors{}
+
+	if length := utf8.RuneCountInString(details.Name); length == 0 {
+		errs = append(errs, newFieldError("name", ErrEmptyContactName, "name must not be empty"))
+	} else if length > MaxContactNameLength {
+		errs = append(errs, newFieldError("name", ErrContactNameTooLong, "name must be at most %d characters", MaxContactNameLength))
+	}
+
+	addresses := 0
+	for _, a := range []struct {
+		field   string
+		channel ChannelType
+		value   string
+	}{
+		{"email", Email, details.Email},
+		{"telegram_chat_id", Telegram, details.TelegramChatID},
+		{"phone", SMS, details.Phone},
+	} {
+		if a.value == "" {
+			continue
+		}
+		addresses++
+		spec, ok := LookupChannel(a.channel)
+		if !ok {
+			errs = append(errs, newFieldError(a.field, ErrUnknownChannel, "channel %s is not available", a.channel))
+			continue
+		}
+		if err := spec.ValidRecipient(a.value); err != nil {
+			errs = append(errs, newFieldError(a.field, ErrInvalidRecipient, "%v", err))
+		}
+	}
+	if addresses == 0 {
+		errs = append(errs, newFieldError("email", ErrMissingAddress, "contact needs at least one of email, telegram_chat_id or phone"))
+	}
+
+	seen := map[ChannelType]bool{}
+	for i, channel := range details.Channels {
+		field := fmt.Sprintf("channels[%d]", i)
+		switch {
+		case !isContactChannel(channel):
+			errs = append(errs, newFieldError(field, ErrInvalidChannels, "channel %q cannot be addressed by contact, use one of email, telegram, sms", channel))
+		case seen[channel]:
+			errs = append(errs, newFieldError(field, ErrInvalidChannels, "channel %s is listed twice", channel))
+		case details.address(channel) == "":
+			errs = append(errs, newFieldError(field, ErrMissingAddress, "contact has no address for %s", channel))
+		}
+		seen[channel] = true
+	}
+
+	if details.Locale != "" && !localeTag.MatchString(details.Locale) {
+		errs = append(errs, newFieldError("locale", ErrInvalidLocale, "%q is not a BCP 47 language tag", details.Locale))
+	}
+	if details.Timezone != "" {
+		if _, err := time.LoadLocation(details.Timezone); err != nil {
+			errs = append(errs, newFieldError("timezone", ErrUnknownTimezone, "unknown timezone %q", details.Timezone))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if len(details.Channels) == 0 {
+		for _, channel := range contactChannels {
+			if details.address(channel) != "" {
+				details.Channels = append(details.Channels, channel)
+			}
+		}
+	}
+	c.ContactDetails = details
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+func isContactChannel(channel ChannelType) bool {
+	for _, c := range contactChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func (d ContactDetails) address(channel ChannelType) string {
+	switch channel {
+	case Email:
+		return d.Email
+	case Telegram:
+		return d.TelegramChatID
+	case SMS:
+		return d.Phone
+	}
+	return ""
+}
+
+// Route возвращает каналы для сообщения по предпочтениям контакта: только доступные
+// (available) и вмещающие текст. Первый — основной, остальные — запасные.
+func (c *Contact) Route(message string, available func(ChannelType) bool) []Destination {
+	var route []Destination
+	for _, channel := range c.Channels {
+		address := c.address(channel)
+		spec, ok := LookupChannel(channel)
+		if address == "" || !ok || !available(channel) || spec.checkMessage(message) != nil {
+			continue
+		}
+		route = append(route, Destination{Channel: channel, Recipient: address})
+	}
+	return route
+}
+
+// NewContactNotification создает уведомление для контакта. Канал выбирается по предпочтениям
+// контакта (см. Route) среди тех, что принимают content, и проверяется как в NewRichNotification;
+// остальные подходящие каналы контакта становятся запасными. При отправке выбор повторяется,
+// чтобы учесть изменения контакта. Без timezone send_at трактуется в поясе контакта.
+func NewContactNotification(contact *Contact, message, sendAt, timezone string, content RichContent) (*Notification, error) {
+	route := contact.Route(message, func(channel ChannelType) bool {
+		return len(validateRichContent(nil, channel, &content)) == 0
+	})
+	if len(route) == 0 {
+		if len(contact.Channels) == 0 {
+			return nil, ValidationErrors{newFieldError("contact_id", ErrMissingAddress, "contact %s has no channels", contact.ID)}
+		}
+		// ни один канал не вмещает текст — пусть проверка первого канала объяснит почему
+		route = []Destination{{Channel: contact.Channels[0], Recipient: contact.address(contact.Channels[0])}}
+	}
+	if timezone == "" {
+		timezone = contact.Timezone
+	}
+
+	n, err := NewRichNotification(string(route[0].Channel), message, route[0].Recipient, sendAt, timezone, content)
+	if err != nil {
+		return nil, err
+	}
+	n.ContactID = &contact.ID
+	n.Fallbacks = route[1:]
+	return n, nil
+}
+
+// ContactNotFound — ошибка проверки contact_id, которого нет в справочнике.
+func ContactNotFound(id string) error {
+	return ValidationErrors{newFieldError("contact_id", ErrUnknownContact, "contact %s not found", id)}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNewContactDefaultsChannels(t *testing.T) {
+	c, err := NewContact(ContactDetails{Name: " Алиса ", TelegramChatID: "@alice_smith", Email: "alice@example.com", Locale: "ru-RU", Timezone: "Europe/Moscow"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Алиса", c.Name)
+	// без явных предпочтений — все каналы с адресом в порядке по умолчанию
+	assert.Equal(t, []ChannelType{Email, Telegram}, c.Channels)
+}
+
+func TestNewContactInvalid(t *testing.T) {
+	_, err := NewContact(ContactDetails{
+		Name:           strings.Repeat("я", MaxContactNameLength+1),
+		TelegramChatID: "alice",
+		Channels:       []ChannelType{Telegram, "slack", Telegram, Email},
+		Locale:         "русский",
+		Timezone:       "Mars/Olympus",
+	})
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"name", "telegram_chat_id", "channels[1]", "channels[2]", "channels[3]", "locale", "timezone"}, fields)
+	assert.ErrorIs(t, errs[4], ErrMissingAddress)
+
+	_, err = NewContact(ContactDetails{Name: "Nobody"})
+	assert.ErrorIs(t, err, ErrMissingAddress)
+}
+
+func TestNewContactNotification(t *testing.T) {
+	c, _ := NewContact(ContactDetails{Name: "Alice", TelegramChatID: "123", Email: "alice@example.com", Channels: []ChannelType{Telegram, Email}, Timezone: "Europe/Moscow"})
+
+	n, err := NewContactNotification(c, "Hi", "2030-03-01T09:00:00", "", RichContent{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Telegram, n.Channel)
+	assert.Equal(t, "123", n.Recipient)
+	assert.Equal(t, c.ID, *n.ContactID)
+	// send_at без смещения трактуется в поясе контакта
+	assert.Equal(t, "2030-03-01T06:00:00Z", n.SendAt.UTC().Format("2006-01-02T15:04:05Z07:00"))
+
+	err = n.ApplyPatch(NotificationPatch{Recipient: &n.Recipient})
+	assert.ErrorIs(t, err, ErrInvalidRecipient)
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// DeadLetterMalformed — причина для сообщений, тело которых не удалось разобрать как уведомление.
+const DeadLetterMalformed = "malformed"
+
+// DeadLetter — сообщение, попавшее в dead-letter очередь и сохраненное для разбора и повторной отправки.
+type DeadLetter struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	NotificationID *uuid.UUID `db:"notification_id" json:"notification_id,omitempty"`
+	Reason         string     `db:"reason" json:"reason"`
+	Body           string     `db:"body" json:"body"`
+	DeathCount     int64      `db:"death_count" json:"death_count"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	ReplayedAt     *time.Time `db:"replayed_at" json:"replayed_at,omitempty"`
+}
+
+// NewDeadLetter разбирает тело сообщения из dead-letter очереди.
+// Если тело не является уведомлением, причина заменяется на DeadLetterMalformed.
+func NewDeadLetter(body []byte, reason string, deathCount int64) *DeadLetter {
+	dl := &DeadLetter{
+		ID:         uuid.New(),
+		Reason:     reason,
+		Body:       strings.ReplaceAll(strings.ToValidUTF8(string(body), "�"), "\x00", ""),
+		DeathCount: deathCount,
+		CreatedAt:  time.Now(),
+	}
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil || n.ID == uuid.Nil {
+		dl.Reason = DeadLetterMalformed
+		return dl
+	}
+	dl.NotificationID = &n.ID
+	return dl
+}
+
+// Notification восстанавливает уведомление из тела сообщения.
+func (d *DeadLetter) Notification() (*Notification, error) {
+	var n Notification
+	if err := json.Unmarshal([]byte(d.Body), &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// PrepareForReplay сбрасывает состояние доставки, чтобы уведомление снова прошло основной поток:
+// отправка начинается с основного канала и, при неудаче, снова проходит всю цепочку запасных.
+func (n *Notification) PrepareForReplay() {
+	n.rewindFallbacks()
+	n.Status = Pending
+	n.Attempts = 0
+	n.NextAttemptAt = nil
+	n.LastError = ""
+	n.UpdatedAt = time.Now()
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewDeadLetterFromNotification(t *testing.T) {
+	n, _ := NewNotification("email", "Hello", "test@example.com", "2030-01-01T10:00:00Z", "")
+	body, _ := json.Marshal(n)
+
+	dl := NewDeadLetter(body, "rejected", 1)
+
+	assert.Equal(t, "rejected", dl.Reason)
+	assert.Equal(t, n.ID, *dl.NotificationID)
+
+	restored, err := dl.Notification()
+	assert.NoError(t, err)
+	assert.Equal(t, n.Message, restored.Message)
+}
+
+func TestNewDeadLetterMalformedBody(t *testing.T) {
+	dl := NewDeadLetter([]byte("not a json"), "rejected", 1)
+
+	assert.Equal(t, DeadLetterMalformed, dl.Reason)
+	assert.Nil(t, dl.NotificationID)
+	assert.Equal(t, "not a json", dl.Body)
+}
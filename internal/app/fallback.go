@@ -0,0 +1,152 @@
+package app
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// MaxFallbacks — предельная длина цепочки запасных каналов уведомления.
+const MaxFallbacks = 5
+
+// Destination — канал и адрес получателя в нем.
+type Destination struct {
+	Channel   ChannelType `json:"channel"`
+	Recipient string      `json:"recipient"`
+}
+
+// SetFallbacks задает запасные каналы в порядке очереди: когда текущий канал исчерпает попытки
+// или вернет постоянную ошибку, консьюмер перейдет к следующему. Каждый запасной канал проверяется
+// по своим правилам (получатель, длина текста, необязательные части вроде кнопок или вложений);
+// ошибки возвращаются одной ValidationErrors.
+func (n *Notification) SetFallbacks(fallbacks []Destination) error {
+	errs := ValidationErrors{}
+	if len(fallbacks) > MaxFallbacks {
+		errs = append(errs, newFieldError("fallbacks", ErrInvalidFallback, "at most %d fallbacks are allowed", MaxFallbacks))
+	}
+
+	seen := map[Destination]bool{{Channel: n.Channel, Recipient: n.Recipient}: true}
+	for i, f := range fallbacks {
+		field := fmt.Sprintf("fallbacks[%d]", i)
+		spec, ok := LookupChannel(f.Channel)
+		if !ok {
+			errs = append(errs, newFieldError(field+".channel", ErrUnknownChannel, "unknown channel %q", f.Channel))
+			continue
+		}
+		if err := spec.ValidRecipient(f.Recipient); err != nil {
+			errs = append(errs, newFieldError(field+".recipient", ErrInvalidRecipient, "%v", err))
+		}
+		if err := spec.checkMessage(n.Message); err != nil {
+			errs = append(errs, newFieldError(field+".channel", ErrMessageTooLong, "%v", err))
+		}
+		for _, e := range n.validateContentFor(f.Channel) {
+			e.Field = field + "." + e.Field
+			errs = append(errs, e)
+		}
+		if seen[f] {
+			errs = append(errs, newFieldError(field, ErrInvalidFallback, "%s %s is already in the chain", f.Channel, f.Recipient))
+		}
+		seen[f] = true
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	n.Fallbacks = fallbacks
+	return nil
+}
+
+// SupportsContent сообщает, что канал примет необязательные части уведомления (тему, HTML,
+// вложения, разметку, кнопки, тихую доставку) без ошибок проверки.
+func (n *Notification) SupportsContent(channel ChannelType) bool {
+	return len(n.validateContentFor(channel)) == 0
+}
+
+func (n *Notification) validateContentFor(channel ChannelType) ValidationErrors {
+	content := n.richContent()
+	return validateRichContent(nil, channel, &content)
+}
+
+func (n *Notification) richContent() RichContent {
+	return RichContent{
+		Subject:     n.Subject,
+		HTML:        n.HTML,
+		Attachments: n.Attachments,
+		ParseMode:   n.ParseMode,
+		Buttons:     n.Buttons,
+		Silent:      n.Silent,
+	}
+}
+
+// AdvanceFallback переключает уведомление на следующий запасной канал: счетчик попыток
+// начинается заново, отправка — сразу. false — запасных каналов не осталось.
+func (n *Notification) AdvanceFallback(reason string) bool {
+	if n.FallbackStep >= len(n.Fallbacks) {
+		return false
+	}
+	next := n.Fallbacks[n.FallbackStep]
+	if n.FallbackStep == 0 {
+		n.Primary = &Destination{Channel: n.Channel, Recipient: n.Recipient}
+	}
+	n.FallbackStep++
+
+	now := time.Now()
+	n.Channel, n.Recipient = next.Channel, next.Recipient
+	n.Status = Pending
+	n.Attempts = 0
+	n.NextAttemptAt = &now
+	n.LastError = reason
+	n.UpdatedAt = now
+	return true
+}
+
+// rewindFallbacks возвращает уведомление на основной канал, чтобы цепочка запасных прошла заново.
+func (n *Notification) rewindFallbacks() {
+	if n.Primary != nil {
+		n.Channel, n.Recipient = n.Primary.Channel, n.Primary.Recipient
+	}
+	n.Primary = nil
+	n.FallbackStep = 0
+	n.DeliveredChannel = ""
+}
+
+// DeliveryOutcome — результат одной попытки доставки.
+type DeliveryOutcome string
+
+const (
+	AttemptSent   DeliveryOutcome = "sent"
+	AttemptFailed DeliveryOutcome = "failed"
+)
+
+// DeliveryAttempt — запись об одной попытке отправки уведомления: в какой канал и по какому
+// адресу, на каком шаге цепочки запасных каналов и чем она закончилась.
+type DeliveryAttempt struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	NotificationID uuid.UUID       `db:"notification_id" json:"notification_id"`
+	Channel        ChannelType     `db:"channel" json:"channel"`
+	Recipient      string          `db:"recipient" json:"recipient"`
+	Attempt        int             `db:"attempt" json:"attempt"`             // номер попытки в этом канале, с 1
+	FallbackStep   int             `db:"fallback_step" json:"fallback_step"` // 0 — основной канал
+	Outcome        DeliveryOutcome `db:"outcome" json:"outcome"`
+	Error          string          `db:"error" json:"error,omitempty"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// NewDeliveryAttempt описывает только что сделанную попытку отправки n; sendErr == nil — успех.
+func NewDeliveryAttempt(n *Notification, sendErr error) *DeliveryAttempt {
+	a := &DeliveryAttempt{
+		ID:             uuid.New(),
+		NotificationID: n.ID,
+		Channel:        n.Channel,
+		Recipient:      n.Recipient,
+		Attempt:        n.Attempts + 1,
+		FallbackStep:   n.FallbackStep,
+		Outcome:        AttemptSent,
+		CreatedAt:      time.Now(),
+	}
+	if sendErr != nil {
+		a.Outcome = AttemptFailed
+		a.Error = sendErr.Error()
+	}
+	return a
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSetFallbacks(t *testing.T) {
+	n, _ := NewNotification("telegram", "Hi", "123", "2030-03-01T09:00:00Z", "")
+
+	err := n.SetFallbacks([]Destination{{Channel: Email, Recipient: "alice@example.com"}, {Channel: Telegram, Recipient: "@alice_smith"}})
+	assert.NoError(t, err)
+	assert.Len(t, n.Fallbacks, 2)
+	assert.Equal(t, 0, n.FallbackStep)
+}
+
+func TestSetFallbacksInvalid(t *testing.T) {
+	n, _ := NewNotification("email", strings.Repeat("a", 5000), "alice@example.com", "2030-03-01T09:00:00Z", "")
+
+	err := n.SetFallbacks([]Destination{
+		{Channel: "pigeon", Recipient: "roof"},
+		{Channel: Email, Recipient: "alice"},
+		{Channel: Telegram, Recipient: "@alice_smith"},
+		{Channel: Email, Recipient: "alice@example.com"},
+	})
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"fallbacks[0].channel", "fallbacks[1].recipient", "fallbacks[2].channel", "fallbacks[3]"}, fields)
+	assert.ErrorIs(t, errs[2], ErrMessageTooLong)
+	// основной канал не может повторяться в цепочке
+	assert.ErrorIs(t, errs[3], ErrInvalidFallback)
+	assert.Empty(t, n.Fallbacks)
+
+	err = n.SetFallbacks(make([]Destination, MaxFallbacks+1))
+	assert.ErrorIs(t, err, ErrInvalidFallback)
+}
+
+func TestSetFallbacksChecksRichContent(t *testing.T) {
+	n, _ := NewRichNotification("email", "Hi", "alice@example.com", "2030-03-01T09:00:00Z", "", RichContent{Subject: "Заказ"})
+
+	err := n.SetFallbacks([]Destination{{Channel: Telegram, Recipient: "123"}})
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "fallbacks[0].channel", errs[0].Field)
+	assert.ErrorIs(t, errs[0], ErrUnsupportedContent)
+	assert.False(t, n.SupportsContent(Telegram))
+	assert.True(t, n.SupportsContent(Email))
+}
+
+func TestAdvanceFallback(t *testing.T) {
+	n, _ := NewNotification("telegram", "Hi", "123", "2030-03-01T09:00:00Z", "")
+	_ = n.SetFallbacks([]Destination{{Channel: Email, Recipient: "alice@example.com"}})
+	n.Status = Sending
+	n.Attempts = 3
+
+	assert.True(t, n.AdvanceFallback("chat not found"))
+	assert.Equal(t, Email, n.Channel)
+	assert.Equal(t, "alice@example.com", n.Recipient)
+	assert.Equal(t, 1, n.FallbackStep)
+	assert.Equal(t, Pending, n.Status)
+	assert.Equal(t, 0, n.Attempts)
+	assert.NotNil(t, n.NextAttemptAt)
+	assert.Equal(t, "chat not found", n.LastError)
+	assert.Equal(t, &Destination{Channel: Telegram, Recipient: "123"}, n.Primary)
+
+	assert.False(t, n.AdvanceFallback("timeout"))
+	assert.Equal(t, Email, n.Channel)
+
+	n.MarkAsSent()
+	assert.Equal(t, Email, n.DeliveredChannel)
+}
+
+func TestPrepareForReplayRewindsFallbacks(t *testing.T) {
+	n, _ := NewNotification("telegram", "Hi", "123", "2030-03-01T09:00:00Z", "")
+	_ = n.SetFallbacks([]Destination{{Channel: Email, Recipient: "alice@example.com"}, {Channel: Telegram, Recipient: "@alice_smith"}})
+	n.AdvanceFallback("chat not found")
+	n.AdvanceFallback("mailbox is full")
+	n.MarkAsFailed()
+
+	n.PrepareForReplay()
+
+	// переотправка начинается с основного канала и снова проходит всю цепочку
+	assert.Equal(t, Telegram, n.Channel)
+	assert.Equal(t, "123", n.Recipient)
+	assert.Equal(t, 0, n.FallbackStep)
+	assert.Nil(t, n.Primary)
+	assert.Len(t, n.Fallbacks, 2)
+	assert.Equal(t, Pending, n.Status)
+
+	assert.True(t, n.AdvanceFallback("chat not found"))
+	assert.Equal(t, Email, n.Channel)
+}
+
+func TestNewDeliveryAttempt(t *testing.T) {
+	n, _ := NewNotification("email", "Hi", "alice@example.com", "2030-03-01T09:00:00Z", "")
+	n.Attempts = 1
+
+	a := NewDeliveryAttempt(n, errors.New("smtp: 451 try again later"))
+	assert.Equal(t, n.ID, a.NotificationID)
+	assert.Equal(t, Email, a.Channel)
+	assert.Equal(t, 2, a.Attempt)
+	assert.Equal(t, AttemptFailed, a.Outcome)
+	assert.Equal(t, "smtp: 451 try again later", a.Error)
+
+	assert.Equal(t, AttemptSent, NewDeliveryAttempt(n, nil).Outcome)
+}
+
+func TestContactRoute(t *testing.T) {
+	c, _ := NewContact(ContactDetails{Name: "Alice", Email: "alice@example.com", TelegramChatID: "123", Channels: []ChannelType{Telegram, Email}})
+
+	all := func(ChannelType) bool { return true }
+	route := c.Route("Hi", all)
+	assert.Equal(t, []Destination{{Channel: Telegram, Recipient: "123"}, {Channel: Email, Recipient: "alice@example.com"}}, route)
+
+	// недоступный канал пропускается
+	route = c.Route("Hi", func(ch ChannelType) bool { return ch != Telegram })
+	assert.Equal(t, []Destination{{Channel: Email, Recipient: "alice@example.com"}}, route)
+
+	// текст длиннее лимита telegram уходит только в email
+	route = c.Route(strings.Repeat("a", 5000), all)
+	assert.Equal(t, []Destination{{Channel: Email, Recipient: "alice@example.com"}}, route)
+
+	assert.Empty(t, c.Route("Hi", func(ChannelType) bool { return false }))
+
+	n, err := NewContactNotification(c, "Hi", "2030-03-01T09:00:00Z", "", RichContent{})
+	assert.NoError(t, err)
+	assert.Equal(t, Telegram, n.Channel)
+	// остальные каналы контакта становятся запасными
+	assert.Equal(t, []Destination{{Channel: Email, Recipient: "alice@example.com"}}, n.Fallbacks)
+
+	// тему письма telegram не поддерживает — он не попадает ни в основной канал, ни в запасные
+	n, err = NewContactNotification(c, "Hi", "2030-03-01T09:00:00Z", "", RichContent{Subject: "Заказ"})
+	assert.NoError(t, err)
+	assert.Equal(t, Email, n.Channel)
+	assert.Empty(t, n.Fallbacks)
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// MaxIdempotencyKeyLength — предельная длина ключа идемпотентности.
+const MaxIdempotencyKeyLength = 255
+
+var (
+	// ErrInvalidIdempotencyKey — ключ пустой или слишком длинный.
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	// ErrIdempotencyKeyReused — ключ уже использован для запроса с другим содержимым.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+)
+
+// IdempotencyKey связывает ключ клиента с созданным по нему уведомлением.
+// До ExpiresAt повторный запрос с тем же ключом возвращает это уведомление, а не создает новое.
+type IdempotencyKey struct {
+	Key            string    `db:"key" json:"key"`
+	RequestHash    string    `db:"request_hash" json:"request_hash"`
+	NotificationID uuid.UUID `db:"notification_id" json:"notification_id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	ExpiresAt      time.Time `db:"expires_at" json:"expires_at"`
+}
+
+// NewIdempotencyKey проверяет ключ и привязывает его к уведомлению на срок retention.
+// requestHash отличает повтор того же запроса от другого запроса с тем же ключом.
+func NewIdempotencyKey(key, requestHash string, notification *Notification, retention time.Duration) (*IdempotencyKey, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: length must be from 1 to %d", ErrInvalidIdempotencyKey, MaxIdempotencyKeyLength)
+	}
+	now := time.Now()
+	return &IdempotencyKey{
+		Key:            key,
+		RequestHash:    requestHash,
+		NotificationID: notification.ID,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(retention),
+	}, nil
+}
+
+// HashRequest возвращает отпечаток полей запроса на создание уведомления.
+func HashRequest(fields ...string) string {
+	h := sha256.New()
+	for _, f := range fields {
+		// длина перед значением, чтобы ("ab", "c") и ("a", "bc") давали разные хэши
+		fmt.Fprintf(h, "%d:%s;", len(f), f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
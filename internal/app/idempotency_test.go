@@ -0,0 +1,34 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashRequestDistinguishesFieldBoundaries(t *testing.T) {
+	assert.Equal(t, HashRequest("email", "hi"), HashRequest("email", "hi"))
+	assert.NotEqual(t, HashRequest("ab", "c"), HashRequest("a", "bc"))
+}
+
+func TestNewIdempotencyKeySetsExpiry(t *testing.T) {
+	n, _ := NewNotification("email", "hi", "user@example.com", "2030-01-01T00:00:00Z", "")
+
+	k, err := NewIdempotencyKey("order-42", "hash", n, time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, n.ID, k.NotificationID)
+	assert.Equal(t, time.Hour, k.ExpiresAt.Sub(k.CreatedAt))
+}
+
+func TestNewIdempotencyKeyRejectsInvalidKey(t *testing.T) {
+	n, _ := NewNotification("email", "hi", "user@example.com", "2030-01-01T00:00:00Z", "")
+
+	_, err := NewIdempotencyKey("", "hash", n, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidIdempotencyKey))
+
+	_, err = NewIdempotencyKey(strings.Repeat("k", MaxIdempotencyKeyLength+1), "hash", n, time.Hour)
+	assert.True(t, errors.Is(err, ErrInvalidIdempotencyKey))
+}
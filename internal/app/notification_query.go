@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// ErrInvalidCursor — курсор поврежден или выдан для другой сортировки.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortBySendAt    SortField = "send_at"
+	SortByUpdatedAt SortField = "updated_at"
+)
+
+type SortOrder string
+
+const (
+	Asc  SortOrder = "asc"
+	Desc SortOrder = "desc"
+)
+
+// ParseSortField проверяет имя поля сортировки; пустая строка — сортировка по created_at.
+func ParseSortField(s string) (SortField, error) {
+	switch f := SortField(s); f {
+	case "":
+		return SortByCreatedAt, nil
+	case SortByCreatedAt, SortBySendAt, SortByUpdatedAt:
+		return f, nil
+	}
+	return "", fmt.Errorf("unsupported sort field %q", s)
+}
+
+// ParseSortOrder проверяет направление сортировки; по умолчанию — от новых к старым.
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch o := SortOrder(s); o {
+	case "":
+		return Desc, nil
+	case Asc, Desc:
+		return o, nil
+	}
+	return "", fmt.Errorf("unsupported sort order %q", s)
+}
+
+// NotificationFilter — условия выборки уведомлений. Пустые поля не ограничивают выборку,
+// границы диапазонов: From включительно, To не включительно.
+type NotificationFilter struct {
+	Statuses    []StatusType
+	Channel     ChannelType
+	Recipient   string
+	SendFrom    *time.Time
+	SendTo      *time.Time
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// Query — подстрока для поиска по тексту сообщения без учета регистра
+	Query string
+}
+
+// NotificationQuery — запрос страницы уведомлений.
+type NotificationQuery struct {
+	Filter NotificationFilter
+	Sort   SortField
+	Order  SortOrder
+	Limit  int
+	// After — позиция последнего элемента предыдущей страницы, nil для первой страницы
+	After *Cursor
+}
+
+// Cursor указывает на элемент выборки: значение поля сортировки и ID для однозначного порядка.
+type Cursor struct {
+	Sort  SortField `json:"s"`
+	Value time.Time `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// CursorFor строит курсор, указывающий на уведомление n при сортировке по полю sort.
+func CursorFor(n *Notification, sort SortField) Cursor {
+	return Cursor{Sort: sort, Value: n.SortValue(sort), ID: n.ID}
+}
+
+// SortValue возвращает значение поля, по которому сортируется выборка.
+func (n *Notification) SortValue(sort SortField) time.Time {
+	switch sort {
+	case SortBySendAt:
+		return n.SendAt
+	case SortByUpdatedAt:
+		return n.UpdatedAt
+	default:
+		return n.CreatedAt
+	}
+}
+
+// Encode упаковывает курсор в непрозрачную для клиента строку.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor разбирает курсор и проверяет, что он выдан для той же сортировки.
+func DecodeCursor(s string, sort SortField) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sort {
+		return nil, fmt.Errorf("%w: issued for sort %q", ErrInvalidCursor, c.Sort)
+	}
+	return &c, nil
+}
+
+// NotificationPage — страница выборки. NextCursor пуст, если страница последняя.
+type NotificationPage struct {
+	Items      []*Notification `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// NewNotificationPage формирует страницу из выборки, запрошенной с запасом в один элемент:
+// если элементов больше limit, лишний отбрасывается и выдается курсор на последний оставшийся.
+func NewNotificationPage(items []*Notification, limit int, sort SortField) *NotificationPage {
+	page := &NotificationPage{Items: items}
+	if page.Items == nil {
+		page.Items = []*Notification{}
+	}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = CursorFor(page.Items[limit-1], sort).Encode()
+	}
+	return page
+}
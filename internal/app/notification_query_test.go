@@ -0,0 +1,70 @@
+package app
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	n := &Notification{ID: uuid.New(), SendAt: time.Date(2030, 1, 2, 3, 4, 5, 6, time.UTC)}
+	encoded := CursorFor(n, SortBySendAt).Encode()
+
+	c, err := DecodeCursor(encoded, SortBySendAt)
+
+	assert.NoError(t, err)
+	assert.Equal(t, n.ID, c.ID)
+	assert.True(t, n.SendAt.Equal(c.Value))
+}
+
+func TestDecodeCursorRejectsOtherSort(t *testing.T) {
+	n := &Notification{ID: uuid.New(), CreatedAt: time.Now()}
+	encoded := CursorFor(n, SortByCreatedAt).Encode()
+
+	_, err := DecodeCursor(encoded, SortBySendAt)
+
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	_, err := DecodeCursor("not a cursor!", SortByCreatedAt)
+
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+func TestNewNotificationPageTrimsExtraItem(t *testing.T) {
+	items := []*Notification{
+		{ID: uuid.New(), CreatedAt: time.Now()},
+		{ID: uuid.New(), CreatedAt: time.Now()},
+		{ID: uuid.New(), CreatedAt: time.Now()},
+	}
+
+	page := NewNotificationPage(items, 2, SortByCreatedAt)
+
+	assert.Len(t, page.Items, 2)
+	c, err := DecodeCursor(page.NextCursor, SortByCreatedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, items[1].ID, c.ID)
+}
+
+func TestNewNotificationPageLastPage(t *testing.T) {
+	page := NewNotificationPage(nil, 10, SortByCreatedAt)
+
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestParseSortDefaults(t *testing.T) {
+	f, err := ParseSortField("")
+	assert.NoError(t, err)
+	assert.Equal(t, SortByCreatedAt, f)
+
+	o, err := ParseSortOrder("")
+	assert.NoError(t, err)
+	assert.Equal(t, Desc, o)
+
+	_, err = ParseSortField("message")
+	assert.Error(t, err)
+}
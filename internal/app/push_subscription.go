@@ -0,0 +1,61 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPushSubscription — адрес или ключи push-подписки некорректны.
+var ErrInvalidPushSubscription = errors.New("invalid push subscription")
+
+// PushSubscription — подписка браузера на Web Push (PushSubscription из Push API).
+// Recipient связывает подписки с получателем уведомлений канала webpush: у одного получателя
+// может быть несколько браузеров и устройств.
+type PushSubscription struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Recipient string    `db:"recipient" json:"recipient"`
+	Endpoint  string    `db:"endpoint" json:"endpoint"`
+	P256dh    string    `db:"p256dh" json:"p256dh"` // открытый ключ браузера, base64url
+	Auth      string    `db:"auth" json:"auth"`     // секрет аутентификации, base64url
+	UserAgent string    `db:"user_agent" json:"user_agent,omitempty"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func NewPushSubscription(recipient, endpoint, p256dh, auth, userAgent string) (*PushSubscription, error) {
+	if strings.TrimSpace(recipient) == "" {
+		return nil, fmt.Errorf("%w: recipient must not be empty", ErrInvalidPushSubscription)
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidPushSubscription)
+	}
+	// P-256 в несжатом виде — 65 байт, секрет — 16 байт (RFC 8291)
+	if key, err := DecodeBase64URL(p256dh); err != nil || len(key) != 65 || key[0] != 0x04 {
+		return nil, fmt.Errorf("%w: p256dh must be an uncompressed P-256 public key", ErrInvalidPushSubscription)
+	}
+	if secret, err := DecodeBase64URL(auth); err != nil || len(secret) != 16 {
+		return nil, fmt.Errorf("%w: auth must be a 16-byte secret", ErrInvalidPushSubscription)
+	}
+
+	now := time.Now()
+	return &PushSubscription{
+		ID:        uuid.New(),
+		Recipient: recipient,
+		Endpoint:  endpoint,
+		P256dh:    p256dh,
+		Auth:      auth,
+		UserAgent: userAgent,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+// DecodeBase64URL декодирует base64url с дополнением и без: браузеры отдают ключи без него.
+func DecodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewPushSubscription(t *testing.T) {
+	key, _ := ecdh.P256().GenerateKey(rand.Reader)
+	p256dh := base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes())
+	auth := base64.URLEncoding.EncodeToString(make([]byte, 16)) // с дополнением тоже принимается
+
+	s, err := NewPushSubscription("user-1", "https://fcm.googleapis.com/fcm/send/abc", p256dh, auth, "Firefox")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user-1", s.Recipient)
+
+	_, err = NewPushSubscription("user-1", "http://push.example.com/abc", p256dh, auth, "")
+	assert.True(t, errors.Is(err, ErrInvalidPushSubscription))
+
+	_, err = NewPushSubscription("user-1", "https://push.example.com/abc", "AAAA", auth, "")
+	assert.True(t, errors.Is(err, ErrInvalidPushSubscription))
+
+	_, err = NewPushSubscription("user-1", "https://push.example.com/abc", p256dh, "c2hvcnQ", "")
+	assert.True(t, errors.Is(err, ErrInvalidPushSubscription))
+}
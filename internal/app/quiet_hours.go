@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// localTimeLayout — время без смещения, которое трактуется в заданном часовом поясе.
+const localTimeLayout = "2006-01-02T15:04:05"
+
+// ErrInvalidQuietHours — часовой пояс или границы тихих часов некорректны.
+var ErrInvalidQuietHours = errors.New("invalid quiet hours")
+
+// ParseSendAt разбирает время отправки. Без timezone ожидается RFC3339 со смещением.
+// С timezone (IANA, например Europe/Moscow) допускается и локальное время без смещения —
+// "2030-03-01T09:00:00" означает 09:00 в этом поясе; время со смещением принимается как есть.
+func ParseSendAt(value, timezone string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if timezone == "" {
+		return time.Time{}, fmt.Errorf("%w: must be RFC3339 with an offset or timezone must be set: %q", ErrInvalidSendAt, value)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w %q", ErrUnknownTimezone, timezone)
+	}
+	t, err := time.ParseInLocation(localTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: must be RFC3339 or local time %s: %q", ErrInvalidSendAt, localTimeLayout, value)
+	}
+	return t, nil
+}
+
+// QuietHours — ежедневное окно в часовом поясе получателя, в которое уведомления не отправляются.
+// Окно может переходить через полночь (22:00–08:00). Пустой Channel означает все каналы.
+type QuietHours struct {
+	Recipient string      `db:"recipient" json:"recipient"`
+	Channel   ChannelType `db:"channel" json:"channel,omitempty"`
+	Timezone  string      `db:"timezone" json:"timezone"`
+	Start     string      `db:"start_time" json:"start"` // ЧЧ:ММ
+	End       string      `db:"end_time" json:"end"`     // ЧЧ:ММ, не включительно
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+func NewQuietHours(recipient, channel, timezone, start, end string) (*QuietHours, error) {
+	if recipient == "" {
+		return nil, fmt.Errorf("%w: recipient must not be empty", ErrInvalidQuietHours)
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	now := time.Now()
+	q := &QuietHours{
+		Recipient: recipient,
+		Channel:   ChannelType(channel),
+		Timezone:  timezone,
+		Start:     start,
+		End:       end,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidQuietHours, timezone)
+	}
+	startAt, err := parseClock(start)
+	if err != nil {
+		return nil, err
+	}
+	endAt, err := parseClock(end)
+	if err != nil {
+		return nil, err
+	}
+	if startAt == endAt {
+		return nil, fmt.Errorf("%w: start and end must differ", ErrInvalidQuietHours)
+	}
+	return q, nil
+}
+
+// parseClock переводит "ЧЧ:ММ" в смещение от полуночи.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time must be HH:MM, got %q", ErrInvalidQuietHours, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// NextAllowed возвращает t, если t вне тихих часов, иначе момент их окончания.
+func (q *QuietHours) NextAllowed(t time.Time) time.Time {
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return t
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return t
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return t
+	}
+
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	// смещение считаем по часам на циферблате, а не по прошедшему времени, чтобы переход на летнее время не сдвигал окно
+	sinceMidnight := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute +
+		time.Duration(local.Second())*time.Second + time.Duration(local.Nanosecond())
+	endOn := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), 0, int(end/time.Minute), 0, 0, loc)
+	}
+
+	if start < end {
+		if sinceMidnight >= start && sinceMidnight < end {
+			return endOn(midnight)
+		}
+		return t
+	}
+	// окно через полночь: [start, 24:00) и [00:00, end)
+	if sinceMidnight >= start {
+		return endOn(midnight.AddDate(0, 0, 1))
+	}
+	if sinceMidnight < end {
+		return endOn(midnight)
+	}
+	return t
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseSendAtLocalTimeInZone(t *testing.T) {
+	sendAt, err := ParseSendAt("2030-03-01T09:00:00", "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.True(t, sendAt.Equal(time.Date(2030, 3, 1, 6, 0, 0, 0, time.UTC)))
+}
+
+func TestParseSendAtKeepsExplicitOffset(t *testing.T) {
+	sendAt, err := ParseSendAt("2030-03-01T09:00:00+02:00", "Europe/Moscow")
+
+	assert.NoError(t, err)
+	assert.True(t, sendAt.Equal(time.Date(2030, 3, 1, 7, 0, 0, 0, time.UTC)))
+}
+
+func TestParseSendAtRequiresOffsetWithoutZone(t *testing.T) {
+	_, err := ParseSendAt("2030-03-01T09:00:00", "")
+	assert.Error(t, err)
+
+	_, err = ParseSendAt("2030-03-01T09:00:00", "Mars/Olympus")
+	assert.Error(t, err)
+}
+
+func TestQuietHoursOvernightWindow(t *testing.T) {
+	q, err := NewQuietHours("123", "telegram", "Europe/Moscow", "22:00", "08:00")
+	assert.NoError(t, err)
+
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	lateEvening := time.Date(2030, 3, 1, 23, 15, 0, 0, msk)
+	earlyMorning := time.Date(2030, 3, 2, 6, 0, 0, 0, msk)
+	afternoon := time.Date(2030, 3, 2, 14, 0, 0, 0, msk)
+	nextMorning := time.Date(2030, 3, 2, 8, 0, 0, 0, msk)
+
+	assert.True(t, q.NextAllowed(lateEvening).Equal(nextMorning))
+	assert.True(t, q.NextAllowed(earlyMorning).Equal(nextMorning))
+	assert.True(t, q.NextAllowed(afternoon).Equal(afternoon))
+}
+
+func TestQuietHoursSameDayWindow(t *testing.T) {
+	q, err := NewQuietHours("user@example.com", "", "UTC", "12:00", "14:30")
+	assert.NoError(t, err)
+
+	lunch := time.Date(2030, 3, 1, 13, 0, 0, 0, time.UTC)
+
+	assert.True(t, q.NextAllowed(lunch).Equal(time.Date(2030, 3, 1, 14, 30, 0, 0, time.UTC)))
+	assert.True(t, q.NextAllowed(lunch.Add(2*time.Hour)).Equal(lunch.Add(2*time.Hour)))
+}
+
+func TestNewQuietHoursRejectsInvalidInput(t *testing.T) {
+	_, err := NewQuietHours("123", "telegram", "UTC", "25:00", "08:00")
+	assert.True(t, errors.Is(err, ErrInvalidQuietHours))
+
+	_, err = NewQuietHours("123", "telegram", "UTC", "08:00", "08:00")
+	assert.True(t, errors.Is(err, ErrInvalidQuietHours))
+
+	_, err = NewQuietHours("123", "telegram", "Nowhere/City", "22:00", "08:00")
+	assert.True(t, errors.Is(err, ErrInvalidQuietHours))
+}
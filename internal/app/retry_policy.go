@@ -0,0 +1,60 @@
+package app
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy описывает повторные попытки доставки уведомления:
+// экспоненциальный backoff с ограничением сверху и случайным джиттером.
+type RetryPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+	Multiplier  float64
+	MaxDelay    time.Duration
+	Jitter      float64 // доля задержки, на которую она может случайно отклониться (0..1)
+}
+
+// Exhausted сообщает, исчерпаны ли попытки после attempts неудачных отправок.
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Backoff возвращает задержку перед следующей попыткой без учета джиттера.
+// attempt — номер уже неудавшейся попытки, начиная с 1.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.Delay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// NextAttemptAt возвращает момент следующей попытки с учетом джиттера.
+func (p RetryPolicy) NextAttemptAt(now time.Time, attempt int) time.Time {
+	delay := p.Backoff(attempt)
+	if jitter := math.Min(math.Max(p.Jitter, 0), 1); jitter > 0 && delay > 0 {
+		spread := float64(delay) * jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+	return now.Add(delay)
+}
+
+// NextAttemptNotBefore — то же, что NextAttemptAt, но не раньше now+minDelay:
+// так учитывается задержка, которую запросил сам получатель (Retry-After).
+func (p RetryPolicy) NextAttemptNotBefore(now time.Time, attempt int, minDelay time.Duration) time.Time {
+	next := p.NextAttemptAt(now, attempt)
+	if earliest := now.Add(minDelay); next.Before(earliest) {
+		return earliest
+	}
+	return next
+}
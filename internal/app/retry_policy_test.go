@@ -0,0 +1,56 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoffGrowsExponentially(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, Delay: time.Second, Multiplier: 2}
+
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 2*time.Second, p.Backoff(2))
+	assert.Equal(t, 4*time.Second, p.Backoff(3))
+}
+
+func TestRetryPolicyBackoffCappedByMaxDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, Delay: time.Second, Multiplier: 10, MaxDelay: 30 * time.Second}
+
+	assert.Equal(t, 30*time.Second, p.Backoff(5))
+}
+
+func TestRetryPolicyNextAttemptWithinJitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, Delay: 10 * time.Second, Multiplier: 2, Jitter: 0.5}
+	now := time.Now()
+
+	for i := 0; i < 100; i++ {
+		next := p.NextAttemptAt(now, 2)
+		assert.True(t, !next.Before(now.Add(10*time.Second)) && !next.After(now.Add(30*time.Second)))
+	}
+}
+
+func TestRetryPolicyExhausted(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3}
+
+	assert.False(t, p.Exhausted(2))
+	assert.True(t, p.Exhausted(3))
+}
+
+func TestMarkForRetry(t *testing.T) {
+	n := &Notification{Status: Failed}
+	next := time.Now().Add(time.Minute)
+	n.MarkForRetry(next, "smtp timeout")
+
+	assert.Equal(t, Pending, n.Status)
+	assert.Equal(t, next, *n.NextAttemptAt)
+	assert.Equal(t, "smtp timeout", n.LastError)
+}
+
+func TestRetryPolicyNextAttemptNotBefore(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, Delay: time.Second, Multiplier: 2}
+	now := time.Now()
+
+	assert.Equal(t, now.Add(time.Minute), p.NextAttemptNotBefore(now, 1, time.Minute))
+	assert.Equal(t, now.Add(4*time.Second), p.NextAttemptNotBefore(now, 3, time.Second))
+}
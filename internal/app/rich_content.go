@@ -0,0 +1,173 @@
+package app
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// Пределы содержимого письма: вложения хранятся в БД вместе с уведомлением и проходят через
+// очередь, поэтому допускаются только небольшие файлы.
+const (
+	MaxSubjectLength        = 255       // в символах
+	MaxHTMLSize             = 200 << 10 // в байтах
+	MaxAttachments          = 5
+	MaxAttachmentsSize      = 1 << 20 // суммарно, в байтах после декодирования base64
+	MaxAttachmentNameLength = 255
+)
+
+// Attachment — файл, приложенный к письму. В JSON содержимое передается в base64.
+type Attachment struct {
+	Filename    string `json:"filename" example:"report.pdf"`
+	ContentType string `json:"content_type,omitempty" example:"application/pdf"`
+	Content     []byte `json:"content" swaggertype:"string" format:"base64"`
+}
+
+// Пределы кнопок под сообщением (ограничения Telegram).
+const (
+	MaxButtonsPerRow    = 8
+	MaxButtonTextLength = 64 // в символах
+)
+
+// Button — кнопка-ссылка под сообщением.
+type Button struct {
+	Text string `json:"text" example:"Открыть заказ"`
+	URL  string `json:"url" example:"https://example.com/orders/42"`
+}
+
+// RichContent — необязательные части уведомления, которые поддерживают не все каналы:
+// тема, HTML-версия текста (Message остается текстовой версией) и вложения — каналы
+// с ChannelSpec.RichContent; разметка текста, кнопки и тихая доставка — каналы
+// с ChannelSpec.ParseModes, MaxButtons и SilentDelivery.
+type RichContent struct {
+	Subject     string
+	HTML        string
+	Attachments []Attachment
+	ParseMode   string
+	Buttons     [][]Button // ряды кнопок
+	Silent      bool
+}
+
+// IsZero сообщает, что ни одна часть не задана.
+func (c RichContent) IsZero() bool {
+	return c.Subject == "" && c.HTML == "" && len(c.Attachments) == 0 &&
+		c.ParseMode == "" && len(c.Buttons) == 0 && !c.Silent
+}
+
+// validateRichContent проверяет необязательные части уведомления по возможностям канала
+// и дописывает ошибки в errs.
+func validateRichContent(errs ValidationErrors, channel ChannelType, content *RichContent) ValidationErrors {
+	if content.IsZero() {
+		return errs
+	}
+	spec, ok := LookupChannel(channel)
+	if !ok {
+		// неизвестный канал уже отмечен validateContent
+		return errs
+	}
+	errs = validateEmailContent(errs, spec, content)
+	return validateMarkup(errs, spec, content)
+}
+
+// validateEmailContent проверяет тему, HTML и вложения. Тип вложения, если не задан,
+// определяется по расширению имени файла.
+func validateEmailContent(errs ValidationErrors, spec ChannelSpec, content *RichContent) ValidationErrors {
+	if content.Subject == "" && content.HTML == "" && len(content.Attachments) == 0 {
+		return errs
+	}
+	if !spec.RichContent {
+		return append(errs, newFieldError("channel", ErrUnsupportedContent, "%s does not support subject, html or attachments", spec.Name))
+	}
+
+	if length := utf8.RuneCountInString(content.Subject); length > MaxSubjectLength {
+		errs = append(errs, newFieldError("subject", ErrInvalidSubject, "subject is %d characters, at most %d allowed", length, MaxSubjectLength))
+	} else if strings.ContainsAny(content.Subject, "\r\n") || !utf8.ValidString(content.Subject) {
+		errs = append(errs, newFieldError("subject", ErrInvalidSubject, "subject must be a single line of valid UTF-8"))
+	}
+	if len(content.HTML) > MaxHTMLSize {
+		errs = append(errs, newFieldError("html", ErrHTMLTooLarge, "html is %d bytes, at most %d allowed", len(content.HTML), MaxHTMLSize))
+	}
+
+	if len(content.Attachments) > MaxAttachments {
+		return append(errs, newFieldError("attachments", ErrAttachmentsTooLarge, "%d attachments, at most %d allowed", len(content.Attachments), MaxAttachments))
+	}
+	total := 0
+	for i := range content.Attachments {
+		a := &content.Attachments[i]
+		field := fmt.Sprintf("attachments[%d]", i)
+		total += len(a.Content)
+
+		if a.Filename == "" || len(a.Filename) > MaxAttachmentNameLength || strings.ContainsAny(a.Filename, "/\\\r\n\x00") || !utf8.ValidString(a.Filename) {
+			errs = append(errs, newFieldError(field+".filename", ErrInvalidAttachment, "filename must be a non-empty file name without path, at most %d bytes", MaxAttachmentNameLength))
+		}
+		if len(a.Content) == 0 {
+			errs = append(errs, newFieldError(field+".content", ErrInvalidAttachment, "attachment must not be empty"))
+		}
+		if a.ContentType == "" {
+			a.ContentType = mime.TypeByExtension(path.Ext(a.Filename))
+			if a.ContentType == "" {
+				a.ContentType = "application/octet-stream"
+			}
+		} else if _, _, err := mime.ParseMediaType(a.ContentType); err != nil {
+			errs = append(errs, newFieldError(field+".content_type", ErrInvalidAttachment, "content_type %q is not a valid media type", a.ContentType))
+		}
+	}
+	if total > MaxAttachmentsSize {
+		errs = append(errs, newFieldError("attachments", ErrAttachmentsTooLarge, "attachments are %d bytes, at most %d allowed", total, MaxAttachmentsSize))
+	}
+	return errs
+}
+
+// validateMarkup проверяет режим разметки, кнопки и тихую доставку. Режим разметки приводится
+// к написанию из ChannelSpec.ParseModes.
+func validateMarkup(errs ValidationErrors, spec ChannelSpec, content *RichContent) ValidationErrors {
+	if content.ParseMode != "" {
+		mode, ok := "", false
+		for _, m := range spec.ParseModes {
+			if strings.EqualFold(m, content.ParseMode) {
+				mode, ok = m, true
+				break
+			}
+		}
+		if ok {
+			content.ParseMode = mode
+		} else if len(spec.ParseModes) == 0 {
+			errs = append(errs, newFieldError("parse_mode", ErrUnsupportedContent, "%s does not support parse_mode", spec.Name))
+		} else {
+			errs = append(errs, newFieldError("parse_mode", ErrUnsupportedContent, "parse_mode must be one of %s", strings.Join(spec.ParseModes, ", ")))
+		}
+	}
+	if content.Silent && !spec.SilentDelivery {
+		errs = append(errs, newFieldError("silent", ErrUnsupportedContent, "%s does not support silent delivery", spec.Name))
+	}
+
+	if len(content.Buttons) == 0 {
+		return errs
+	}
+	if spec.MaxButtons == 0 {
+		return append(errs, newFieldError("buttons", ErrUnsupportedContent, "%s does not support buttons", spec.Name))
+	}
+	total := 0
+	for i, row := range content.Buttons {
+		total += len(row)
+		if len(row) == 0 || len(row) > MaxButtonsPerRow {
+			errs = append(errs, newFieldError(fmt.Sprintf("buttons[%d]", i), ErrInvalidButton, "row must have from 1 to %d buttons", MaxButtonsPerRow))
+		}
+		for j, b := range row {
+			field := fmt.Sprintf("buttons[%d][%d]", i, j)
+			if length := utf8.RuneCountInString(strings.TrimSpace(b.Text)); length == 0 || length > MaxButtonTextLength {
+				errs = append(errs, newFieldError(field+".text", ErrInvalidButton, "text must be from 1 to %d characters", MaxButtonTextLength))
+			}
+			if u, err := url.Parse(b.URL); err != nil || (u.Scheme != "https" && u.Scheme != "http" && u.Scheme != "tg") || (u.Scheme != "tg" && u.Host == "") {
+				errs = append(errs, newFieldError(field+".url", ErrInvalidButton, "url must be an http(s) or tg:// link"))
+			}
+		}
+	}
+	if total > spec.MaxButtons {
+		errs = append(errs, newFieldError("buttons", ErrInvalidButton, "%d buttons, %s allows at most %d", total, spec.Name, spec.MaxButtons))
+	}
+	return errs
+}
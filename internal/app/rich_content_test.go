@@ -0,0 +1,103 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNewRichNotificationEmail(t *testing.T) {
+	n, err := NewRichNotification("email", "Отчет готов", "test@example.com", "2030-01-01T10:00:00Z", "", RichContent{
+		Subject:     "Ежемесячный отчет",
+		HTML:        "<p>Отчет <b>готов</b></p>",
+		Attachments: []Attachment{{Filename: "report.pdf", Content: []byte("%PDF-1.4")}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Ежемесячный отчет", n.Subject)
+	assert.Equal(t, "<p>Отчет <b>готов</b></p>", n.HTML)
+	// тип вложения определяется по расширению
+	assert.Equal(t, "application/pdf", n.Attachments[0].ContentType)
+}
+
+func TestNewRichNotificationUnsupportedChannel(t *testing.T) {
+	_, err := NewRichNotification("telegram", "Hi", "123", "2030-01-01T10:00:00Z", "", RichContent{Subject: "Hello"})
+
+	assert.ErrorIs(t, err, ErrUnsupportedContent)
+}
+
+func TestNewRichNotificationInvalidContent(t *testing.T) {
+	_, err := NewRichNotification("email", "Hi", "test@example.com", "2030-01-01T10:00:00Z", "", RichContent{
+		Subject: "Hello\r\nBcc: victim@example.com",
+		HTML:    strings.Repeat("a", MaxHTMLSize+1),
+		Attachments: []Attachment{
+			{Filename: "../etc/passwd", Content: []byte("x")},
+			{Filename: "empty.txt"},
+			{Filename: "data.bin", ContentType: "not a type", Content: []byte("x")},
+		},
+	})
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"subject", "html", "attachments[0].filename", "attachments[1].content", "attachments[2].content_type"}, fields)
+	assert.Equal(t, "too_long", errs[1].Code)
+}
+
+func TestNewRichNotificationAttachmentLimits(t *testing.T) {
+	many := make([]Attachment, MaxAttachments+1)
+	for i := range many {
+		many[i] = Attachment{Filename: "a.txt", Content: []byte("x")}
+	}
+	_, err := NewRichNotification("email", "Hi", "test@example.com", "2030-01-01T10:00:00Z", "", RichContent{Attachments: many})
+	assert.ErrorIs(t, err, ErrAttachmentsTooLarge)
+
+	big := []Attachment{{Filename: "big.bin", Content: make([]byte, MaxAttachmentsSize+1)}}
+	_, err = NewRichNotification("email", "Hi", "test@example.com", "2030-01-01T10:00:00Z", "", RichContent{Attachments: big})
+	assert.ErrorIs(t, err, ErrAttachmentsTooLarge)
+}
+
+func TestNewRichNotificationTelegramMarkup(t *testing.T) {
+	n, err := NewRichNotification("telegram", "*Заказ* отправлен", "123", "2030-01-01T10:00:00Z", "", RichContent{
+		ParseMode: "markdownv2",
+		Buttons:   [][]Button{{{Text: "Отследить", URL: "https://example.com/track/1"}}},
+		Silent:    true,
+	})
+
+	assert.NoError(t, err)
+	// режим приводится к написанию, которое ждет Telegram
+	assert.Equal(t, "MarkdownV2", n.ParseMode)
+	assert.Len(t, n.Buttons, 1)
+	assert.True(t, n.Silent)
+}
+
+func TestNewRichNotificationInvalidMarkup(t *testing.T) {
+	_, err := NewRichNotification("telegram", "Hi", "123", "2030-01-01T10:00:00Z", "", RichContent{
+		ParseMode: "Markdown",
+		Buttons: [][]Button{
+			{},
+			{{Text: " ", URL: "javascript:alert(1)"}},
+		},
+	})
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	assert.Equal(t, []string{"parse_mode", "buttons[0]", "buttons[1][0].text", "buttons[1][0].url"}, fields)
+}
+
+func TestNewRichNotificationMarkupUnsupportedChannel(t *testing.T) {
+	_, err := NewRichNotification("email", "Hi", "test@example.com", "2030-01-01T10:00:00Z", "", RichContent{
+		Buttons: [][]Button{{{Text: "Open", URL: "https://example.com"}}},
+		Silent:  true,
+	})
+
+	assert.ErrorIs(t, err, ErrUnsupportedContent)
+}
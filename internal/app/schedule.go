@@ -0,0 +1,264 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"github.com/teambition/rrule-go"
+	"strings"
+	"time"
+)
+
+type ScheduleKind string
+
+const (
+	// ScheduleCron — стандартное cron-выражение из пяти полей или дескриптор (@daily, @every 1h)
+	ScheduleCron ScheduleKind = "cron"
+	// ScheduleRRule — правило повторения iCalendar (RFC 5545), например FREQ=WEEKLY;BYDAY=MO,FR
+	ScheduleRRule ScheduleKind = "rrule"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	SchedulePaused    ScheduleStatus = "paused"
+	ScheduleCompleted ScheduleStatus = "completed"
+)
+
+var (
+	// ErrInvalidSchedule — выражение, вид или границы расписания некорректны.
+	ErrInvalidSchedule = errors.New("invalid schedule")
+	// ErrScheduleConflict — статус расписания не позволяет выполнить операцию.
+	ErrScheduleConflict = errors.New("schedule status conflict")
+)
+
+// Schedule — повторяющееся уведомление. Продюсер заранее порождает из него обычные уведомления
+// на каждое срабатывание в пределах горизонта (см. Materialize).
+type Schedule struct {
+	ID             uuid.UUID      `db:"id" json:"id"`
+	Channel        ChannelType    `db:"channel" json:"channel"`
+	Recipient      string         `db:"recipient" json:"recipient"`
+	Message        string         `db:"message" json:"message"`
+	Kind           ScheduleKind   `db:"kind" json:"kind"`
+	Expression     string         `db:"expression" json:"expression"`
+	Timezone       string         `db:"timezone" json:"timezone"`
+	StartAt        time.Time      `db:"start_at" json:"start_at"`
+	EndAt          *time.Time     `db:"end_at" json:"end_at,omitempty"`
+	MaxOccurrences int            `db:"max_occurrences" json:"max_occurrences,omitempty"` // 0 — без ограничения
+	Occurrences    int            `db:"occurrences" json:"occurrences"`                   // сколько уведомлений уже порождено
+	NextRunAt      *time.Time     `db:"next_run_at" json:"next_run_at,omitempty"`
+	Status         ScheduleStatus `db:"status" json:"status"`
+	CreatedAt      time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// NewSchedule проверяет выражение и часовой пояс и вычисляет первое срабатывание не раньше startAt.
+// Пустой timezone означает UTC, пустой startAt — текущий момент. Ошибки возвращаются одной
+// ValidationErrors, как и при создании уведомления.
+func NewSchedule(channel, message, recipient string, kind ScheduleKind, expression, timezone, startAt string, endAt *string, maxOccurrences int) (*Schedule, error) {
+	errs := validateContent(nil, ChannelType(channel), &message, &recipient)
+	if maxOccurrences < 0 {
+		errs = append(errs, newFieldError("max_occurrences", ErrInvalidSchedule, "max_occurrences must not be negative"))
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		errs = append(errs, newFieldError("timezone", ErrUnknownTimezone, "unknown timezone %q", timezone))
+	}
+
+	now := time.Now()
+	s := &Schedule{
+		ID:             uuid.New(),
+		Channel:        ChannelType(channel),
+		Recipient:      recipient,
+		Message:        message,
+		Kind:           kind,
+		Expression:     strings.TrimSpace(expression),
+		Timezone:       timezone,
+		StartAt:        now.Truncate(time.Second),
+		MaxOccurrences: maxOccurrences,
+		Status:         ScheduleActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if startAt != "" {
+		t, err := time.Parse(time.RFC3339, startAt)
+		if err != nil {
+			errs = append(errs, newFieldError("start_at", ErrInvalidSchedule, "%v", err))
+		} else {
+			s.StartAt = t.Truncate(time.Second)
+		}
+	}
+	if endAt != nil && *endAt != "" {
+		t, err := time.Parse(time.RFC3339, *endAt)
+		switch {
+		case err != nil:
+			errs = append(errs, newFieldError("end_at", ErrInvalidSchedule, "%v", err))
+		case !t.After(s.StartAt):
+			errs = append(errs, newFieldError("end_at", ErrInvalidSchedule, "end_at must be after start_at"))
+		default:
+			s.EndAt = &t
+		}
+	}
+	if s.Kind != ScheduleCron && s.Kind != ScheduleRRule {
+		errs = append(errs, newFieldError("kind", ErrInvalidSchedule, "kind must be %q or %q", ScheduleCron, ScheduleRRule))
+	} else if loc != nil {
+		if _, err := s.compile(loc); err != nil {
+			errs = append(errs, newFieldError("expression", ErrInvalidSchedule, "%v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// start_at в прошлом не порождает пропущенные срабатывания
+	if err := s.rewind(now); err != nil {
+		return nil, err
+	}
+	if s.NextRunAt == nil {
+		return nil, ValidationErrors{newFieldError("expression", ErrInvalidSchedule, "schedule has no occurrences")}
+	}
+	return s, nil
+}
+
+// recurrence возвращает функцию, вычисляющую ближайшее срабатывание после момента after
+// (включая его, если inclusive). Срабатывания считаются в часовом поясе расписания.
+func (s *Schedule) recurrence() (func(after time.Time, inclusive bool) (time.Time, bool), error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: timezone %q: %v", ErrInvalidSchedule, s.Timezone, err)
+	}
+	next, err := s.compile(loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return next, nil
+}
+
+// compile разбирает выражение расписания в часовом поясе loc.
+func (s *Schedule) compile(loc *time.Location) (func(after time.Time, inclusive bool) (time.Time, bool), error) {
+	switch s.Kind {
+	case ScheduleCron:
+		sched, err := cron.ParseStandard(s.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("cron: %v", err)
+		}
+		return func(after time.Time, inclusive bool) (time.Time, bool) {
+			if inclusive {
+				after = after.Add(-time.Nanosecond)
+			}
+			next := sched.Next(after.In(loc))
+			return next, !next.IsZero()
+		}, nil
+	case ScheduleRRule:
+		opt, err := rrule.StrToROptionInLocation(s.Expression, loc)
+		if err != nil {
+			return nil, fmt.Errorf("rrule: %v", err)
+		}
+		// время срабатываний (часы, минуты) берется из start_at, если правило не задает его явно
+		opt.Dtstart = s.StartAt.In(loc)
+		rule, err := rrule.NewRRule(*opt)
+		if err != nil {
+			return nil, fmt.Errorf("rrule: %v", err)
+		}
+		return func(after time.Time, inclusive bool) (time.Time, bool) {
+			next := rule.After(after.In(loc), inclusive)
+			return next, !next.IsZero()
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported kind %q", s.Kind)
+}
+
+// rewind выставляет NextRunAt на первое срабатывание после момента t (но не раньше StartAt).
+// Если срабатываний больше нет, расписание завершается.
+func (s *Schedule) rewind(t time.Time) error {
+	next, err := s.recurrence()
+	if err != nil {
+		return err
+	}
+	if t.Before(s.StartAt) {
+		s.schedule(next(s.StartAt, true))
+	} else {
+		s.schedule(next(t, false))
+	}
+	return nil
+}
+
+// schedule запоминает следующее срабатывание с учетом end_at и max_occurrences.
+func (s *Schedule) schedule(at time.Time, ok bool) {
+	exhausted := s.MaxOccurrences > 0 && s.Occurrences >= s.MaxOccurrences
+	if !ok || exhausted || (s.EndAt != nil && at.After(*s.EndAt)) {
+		s.NextRunAt = nil
+		s.Status = ScheduleCompleted
+		return
+	}
+	s.NextRunAt = &at
+}
+
+// Materialize порождает уведомления на срабатывания не позже horizon, но не больше limit за вызов,
+// и сдвигает NextRunAt. Приостановленное или завершенное расписание ничего не порождает.
+func (s *Schedule) Materialize(horizon time.Time, limit int) ([]*Notification, error) {
+	if s.Status != ScheduleActive || s.NextRunAt == nil || s.NextRunAt.After(horizon) {
+		return nil, nil
+	}
+	next, err := s.recurrence()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var notifications []*Notification
+	for s.Status == ScheduleActive && s.NextRunAt != nil && !s.NextRunAt.After(horizon) && len(notifications) < limit {
+		at := *s.NextRunAt
+		notifications = append(notifications, &Notification{
+			ID:         uuid.New(),
+			ScheduleID: &s.ID,
+			Channel:    s.Channel,
+			Recipient:  s.Recipient,
+			Message:    s.Message,
+			SendAt:     at,
+			Status:     Pending,
+			Version:    1,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		})
+		s.Occurrences++
+		s.schedule(next(at, false))
+	}
+	s.UpdatedAt = now
+	return notifications, nil
+}
+
+// Pause приостанавливает расписание; уже порожденные, но не отправленные уведомления отменяет хранилище.
+func (s *Schedule) Pause() error {
+	if s.Status != ScheduleActive {
+		return fmt.Errorf("%w: only active schedules can be paused, current status %s", ErrScheduleConflict, s.Status)
+	}
+	s.Status = SchedulePaused
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// ReturnOccurrences возвращает в счетчик n срабатываний, уведомления которых отменены при паузе:
+// они так и не были отправлены, и после Resume будущие из них создаются заново.
+func (s *Schedule) ReturnOccurrences(n int) {
+	s.Occurrences -= n
+	if s.Occurrences < 0 {
+		s.Occurrences = 0
+	}
+}
+
+// Resume возобновляет расписание с ближайшего срабатывания после текущего момента:
+// пропущенные за время паузы срабатывания не догоняются.
+func (s *Schedule) Resume() error {
+	if s.Status != SchedulePaused {
+		return fmt.Errorf("%w: only paused schedules can be resumed, current status %s", ErrScheduleConflict, s.Status)
+	}
+	s.Status = ScheduleActive
+	s.UpdatedAt = time.Now()
+	return s.rewind(s.UpdatedAt)
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewScheduleCronInTimezone(t *testing.T) {
+	s, err := NewSchedule("email", "standup", "team@example.com", ScheduleCron, "0 9 * * *", "Europe/Moscow", "2099-01-01T00:00:00Z", nil, 0)
+
+	assert.NoError(t, err)
+	// 09:00 по Москве — 06:00 UTC
+	assert.True(t, s.NextRunAt.Equal(time.Date(2099, 1, 1, 6, 0, 0, 0, time.UTC)))
+}
+
+func TestNewScheduleRRuleTakesTimeFromStart(t *testing.T) {
+	s, err := NewSchedule("email", "report", "boss@example.com", ScheduleRRule, "FREQ=WEEKLY;BYDAY=MO", "UTC", "2099-01-01T10:30:00Z", nil, 0)
+
+	assert.NoError(t, err)
+	// 1 января 2099 — четверг, ближайший понедельник — 5 января
+	assert.True(t, s.NextRunAt.Equal(time.Date(2099, 1, 5, 10, 30, 0, 0, time.UTC)))
+}
+
+func TestNewScheduleRejectsInvalidInput(t *testing.T) {
+	_, err := NewSchedule("email", "hi", "a@b.c", ScheduleCron, "not a cron", "UTC", "", nil, 0)
+	assert.True(t, errors.Is(err, ErrInvalidSchedule))
+
+	_, err = NewSchedule("email", "hi", "a@b.c", ScheduleCron, "@daily", "Mars/Olympus", "", nil, 0)
+	assert.True(t, errors.Is(err, ErrUnknownTimezone))
+
+	_, err = NewSchedule("email", "hi", "a@b.c", ScheduleRRule, "FREQ=SOMETIMES", "UTC", "", nil, 0)
+	assert.True(t, errors.Is(err, ErrInvalidSchedule))
+}
+
+func TestNewScheduleReportsFieldErrors(t *testing.T) {
+	end := "2020-01-01T00:00:00Z"
+	_, err := NewSchedule("email", "hi", "a@b.c", ScheduleCron, "not a cron", "Mars/Olympus", "tomorrow", &end, -1)
+
+	var errs ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	// выражение не проверяется, пока неизвестен часовой пояс
+	assert.Equal(t, []string{"max_occurrences", "timezone", "start_at", "end_at"}, fields)
+	assert.Equal(t, "unknown_timezone", errs[1].Code)
+
+	_, err = NewSchedule("email", "hi", "a@b.c", ScheduleCron, "not a cron", "UTC", "", nil, 0)
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, "expression", errs[0].Field)
+	assert.Equal(t, "invalid_format", errs[0].Code)
+
+	_, err = NewSchedule("email", "hi", "a@b.c", "weekly", "@daily", "UTC", "", nil, 0)
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, "kind", errs[0].Field)
+}
+
+func TestScheduleMaterializeRespectsMaxOccurrences(t *testing.T) {
+	s, err := NewSchedule("telegram", "tick", "123", ScheduleCron, "@hourly", "UTC", "2099-01-01T00:00:00Z", nil, 3)
+	assert.NoError(t, err)
+
+	notifications, err := s.Materialize(time.Date(2099, 1, 2, 0, 0, 0, 0, time.UTC), 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, notifications, 3)
+	assert.Equal(t, s.ID, *notifications[0].ScheduleID)
+	assert.Equal(t, Pending, notifications[0].Status)
+	assert.Equal(t, ScheduleCompleted, s.Status)
+	assert.Nil(t, s.NextRunAt)
+}
+
+func TestScheduleMaterializeStopsAtEndAndLimit(t *testing.T) {
+	end := "2099-01-01T05:30:00Z"
+	s, err := NewSchedule("email", "tick", "a@b.c", ScheduleCron, "@hourly", "UTC", "2099-01-01T00:00:00Z", &end, 0)
+	assert.NoError(t, err)
+
+	first, _ := s.Materialize(time.Date(2099, 1, 2, 0, 0, 0, 0, time.UTC), 2)
+	assert.Len(t, first, 2)
+	assert.Equal(t, ScheduleActive, s.Status)
+
+	rest, _ := s.Materialize(time.Date(2099, 1, 2, 0, 0, 0, 0, time.UTC), 100)
+	// 00:00 … 05:00 — шесть срабатываний до end_at
+	assert.Len(t, rest, 4)
+	assert.Equal(t, ScheduleCompleted, s.Status)
+}
+
+func TestSchedulePauseResume(t *testing.T) {
+	s, err := NewSchedule("email", "tick", "a@b.c", ScheduleCron, "@hourly", "UTC", "", nil, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Pause())
+	notifications, _ := s.Materialize(time.Now().Add(24*time.Hour), 100)
+	assert.Empty(t, notifications)
+	assert.True(t, errors.Is(s.Pause(), ErrScheduleConflict))
+
+	assert.NoError(t, s.Resume())
+	assert.Equal(t, ScheduleActive, s.Status)
+	assert.True(t, s.NextRunAt.After(time.Now()))
+}
+
+func TestSchedulePauseResumeWithinHorizon(t *testing.T) {
+	s, err := NewSchedule("email", "tick", "a@b.c", ScheduleCron, "@hourly", "UTC", "", nil, 5)
+	assert.NoError(t, err)
+	horizon := time.Now().Add(3 * time.Hour)
+	before, _ := s.Materialize(horizon, 100)
+	assert.Len(t, before, 3)
+
+	// пауза до первого срабатывания: хранилище отменяет все три уведомления
+	assert.NoError(t, s.Pause())
+	s.ReturnOccurrences(len(before))
+	assert.NoError(t, s.Resume())
+
+	// после возобновления те же срабатывания создаются заново и не выходят за max_occurrences
+	after, _ := s.Materialize(horizon, 100)
+	assert.Len(t, after, 3)
+	for i := range after {
+		assert.True(t, after[i].SendAt.Equal(before[i].SendAt))
+	}
+	assert.Equal(t, 3, s.Occurrences)
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TelegramSubscriber — чат, который запустил бота командой /start. По username чата
+// уведомления можно адресовать как @username вместо числового chat ID.
+type TelegramSubscriber struct {
+	ChatID       int64     `db:"chat_id" json:"chat_id"`
+	Username     string    `db:"username" json:"username,omitempty"`
+	FirstName    string    `db:"first_name" json:"first_name,omitempty"`
+	SubscribedAt time.Time `db:"subscribed_at" json:"subscribed_at"`
+	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func NewTelegramSubscriber(chatID int64, username, firstName string) *TelegramSubscriber {
+	now := time.Now()
+	return &TelegramSubscriber{
+		ChatID:       chatID,
+		Username:     username,
+		FirstName:    firstName,
+		SubscribedAt: now,
+		UpdatedAt:    now,
+	}
+}
+
+// telegramUsername — правила username в Telegram: 5–32 символа, латиница, цифры и _, начинается с буквы.
+var telegramUsername = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{4,31}$`)
+
+// ParseTelegramUsername возвращает username из получателя вида @username (без @).
+func ParseTelegramUsername(recipient string) (string, bool) {
+	username, ok := strings.CutPrefix(recipient, "@")
+	if !ok || !telegramUsername.MatchString(username) {
+		return "", false
+	}
+	return username, true
+}
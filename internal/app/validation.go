@@ -0,0 +1,150 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// SendAtPastTolerance — насколько send_at может отставать от текущего момента:
+// "отправить сейчас" с клиента с немного отстающими часами не должно отклоняться.
+const SendAtPastTolerance = 5 * time.Minute
+
+// Ошибки проверки полей уведомления. Возвращаются внутри ValidationErrors, проверяются через errors.Is.
+var (
+	ErrInvalidSendAt    = errors.New("invalid send_at")
+	ErrUnknownTimezone  = errors.New("unknown timezone")
+	ErrSendAtInPast     = errors.New("send_at is in the past")
+	ErrUnknownChannel   = errors.New("unknown channel")
+	ErrEmptyMessage     = errors.New("message is empty")
+	ErrMessageTooLong   = errors.New("message is too long")
+	ErrInvalidRecipient = errors.New("invalid recipient")
+
+	ErrUnsupportedContent  = errors.New("content is not supported by channel")
+	ErrInvalidSubject      = errors.New("invalid subject")
+	ErrHTMLTooLarge        = errors.New("html is too large")
+	ErrInvalidAttachment   = errors.New("invalid attachment")
+	ErrAttachmentsTooLarge = errors.New("attachments are too large")
+	ErrInvalidButton       = errors.New("invalid button")
+
+	ErrEmptyContactName   = errors.New("contact name is empty")
+	ErrContactNameTooLong = errors.New("contact name is too long")
+	ErrMissingAddress     = errors.New("contact has no address for channel")
+	ErrInvalidChannels    = errors.New("invalid channel preferences")
+	ErrInvalidLocale      = errors.New("invalid locale")
+	ErrUnknownContact     = errors.New("unknown contact")
+
+	ErrInvalidFallback = errors.New("invalid fallback")
+)
+
+// errorCodes — машиночитаемые коды ошибок для ответа API.
+var errorCodes = map[error]string{
+	ErrInvalidSendAt:    "invalid_format",
+	ErrUnknownTimezone:  "unknown_timezone",
+	ErrSendAtInPast:     "in_past",
+	ErrUnknownChannel:   "unsupported",
+	ErrEmptyMessage:     "required",
+	ErrMessageTooLong:   "too_long",
+	ErrInvalidRecipient: "invalid_recipient",
+
+	ErrUnsupportedContent:  "unsupported",
+	ErrInvalidSubject:      "invalid_format",
+	ErrHTMLTooLarge:        "too_long",
+	ErrInvalidAttachment:   "invalid_format",
+	ErrAttachmentsTooLarge: "too_long",
+	ErrInvalidButton:       "invalid_format",
+
+	ErrEmptyContactName:   "required",
+	ErrContactNameTooLong: "too_long",
+	ErrMissingAddress:     "required",
+	ErrInvalidChannels:    "invalid_format",
+	ErrInvalidLocale:      "invalid_format",
+	ErrUnknownContact:     "not_found",
+
+	ErrInvalidFallback: "invalid_format",
+
+	ErrInvalidSchedule: "invalid_format",
+}
+
+// FieldError — ошибка проверки одного поля: имя поля в API, код и описание.
+type FieldError struct {
+	Field   string `json:"field" example:"send_at"`
+	Code    string `json:"code" example:"in_past"`
+	Message string `json:"message" example:"send_at is in the past"`
+	err     error
+}
+
+func newFieldError(field string, err error, format string, args ...any) FieldError {
+	return FieldError{
+		Field:   field,
+		Code:    errorCodes[err],
+		Message: fmt.Sprintf(format, args...),
+		err:     err,
+	}
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func (e FieldError) Unwrap() error {
+	return e.err
+}
+
+// ValidationErrors — все ошибки проверки входных данных сразу, а не только первая.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, e := range v {
+		errs[i] = e
+	}
+	return errs
+}
+
+// validateContent проверяет канал, а также текст и получателя, если они заданы (nil — поле не проверяется),
+// и дописывает ошибки в errs.
+func validateContent(errs ValidationErrors, channel ChannelType, message, recipient *string) ValidationErrors {
+	spec, ok := LookupChannel(channel)
+	if !ok {
+		return append(errs, newFieldError("channel", ErrUnknownChannel, "unknown channel %q", channel))
+	}
+	if message != nil {
+		if length := utf8.RuneCountInString(*message); length == 0 {
+			errs = append(errs, newFieldError("message", ErrEmptyMessage, "message must not be empty"))
+		} else if err := spec.checkMessage(*message); err != nil {
+			errs = append(errs, newFieldError("message", ErrMessageTooLong, "%v", err))
+		}
+	}
+	if recipient != nil {
+		if err := spec.ValidRecipient(*recipient); err != nil {
+			errs = append(errs, newFieldError("recipient", ErrInvalidRecipient, "%v", err))
+		}
+	}
+	return errs
+}
+
+// validateSendAt разбирает send_at и проверяет, что он не в прошлом дальше SendAtPastTolerance.
+func validateSendAt(errs ValidationErrors, value, timezone string, now time.Time) (time.Time, ValidationErrors) {
+	sendAt, err := ParseSendAt(value, timezone)
+	if err != nil {
+		if errors.Is(err, ErrUnknownTimezone) {
+			return sendAt, append(errs, newFieldError("timezone", ErrUnknownTimezone, "unknown timezone %q", timezone))
+		}
+		return sendAt, append(errs, newFieldError("send_at", ErrInvalidSendAt, "%v", err))
+	}
+	if sendAt.Before(now.Add(-SendAtPastTolerance)) {
+		errs = append(errs, newFieldError("send_at", ErrSendAtInPast, "send_at %s is in the past", sendAt.Format(time.RFC3339)))
+	}
+	return sendAt, errs
+}
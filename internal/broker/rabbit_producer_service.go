@@ -5,7 +5,9 @@ import (
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/rabbitmq/amqp091-go"
 	wbrabbit "github.com/wb-go/wbf/rabbitmq"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
@@ -16,19 +18,33 @@ type RabbitService struct {
 	client    *wbrabbit.Connection
 	channel   *wbrabbit.Channel
 	publisher publisherIface
+	exchange  string
 	cfg       *config.RetrysConfig
+	producer  config.ProducerConfig
 	repo      StorageProvider
+	cache     CacheProvider
 }
 
 type StorageProvider interface {
-	GetNotifications(status app.StatusType, batchSize int, lastId string) ([]*app.Notification, error)
+	ClaimNotifications(batchSize int, lease time.Duration) ([]*app.Notification, error)
+	MarkNotificationQueued(id string) error
+	ReleaseNotification(id string) error
+	ReapExpiredLeases() ([]string, error)
+	MaterializeSchedules(horizon time.Time, batchSize, perSchedule int) (int, error)
+}
+
+// CacheProvider — кэш уведомлений для GET /notify/{id}. Продюсер не знает актуальной версии
+// строки после своих переходов, поэтому не перезаписывает кэш, а сбрасывает запись: следующее
+// чтение возьмет ее из Postgres, а более свежую копию, записанную консьюмером, не затрет.
+type CacheProvider interface {
+	DeleteNotification(id string) error
 }
 
 type publisherIface interface {
-	PublishWithRetry(body []byte, routingKey, contentType string, strategy retry.Strategy, options ...wbrabbit.PublishingOptions) error
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (*amqp091.DeferredConfirmation, error)
 }
 
-func NewRabbitProducerService(cfg *config.AppConfig, repo StorageProvider) (*RabbitService, error) {
+func NewRabbitProducerService(cfg *config.AppConfig, repo StorageProvider, cache CacheProvider) (*RabbitService, error) {
 	rabbitDSN := fmt.Sprintf(
 		"amqp://%s:%s@%s:%d/",
 		cfg.RabbitmqConfig.User,
@@ -55,9 +71,33 @@ func NewRabbitProducerService(cfg *config.AppConfig, repo StorageProvider) (*Rab
 		return nil, err
 	}
 
+	// сообщения, отклоненные консьюмером без requeue, уходят в dead-letter очередь
+	dlx := wbrabbit.NewExchange(cfg.RabbitmqConfig.DeadLetterExchange, "fanout")
+	dlx.Durable = true
+	if err := dlx.BindToChannel(ch); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to declare dead-letter exchange in RabbitMQ")
+		return nil, err
+	}
+
 	qm := wbrabbit.NewQueueManager(ch)
+	dlq, err := qm.DeclareQueue(cfg.RabbitmqConfig.DeadLetterQueue, wbrabbit.QueueConfig{
+		Durable: true,
+	})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to declare dead-letter queue in RabbitMQ")
+		return nil, err
+	}
+
+	if err = ch.QueueBind(dlq.Name, "", dlx.Name(), false, nil); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to bind dead-letter queue in RabbitMQ")
+		return nil, err
+	}
+
 	queue, err := qm.DeclareQueue(cfg.RabbitmqConfig.QueueName, wbrabbit.QueueConfig{
 		Durable: true,
+		Args: amqp091.Table{
+			"x-dead-letter-exchange": dlx.Name(),
+		},
 	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to declare queue in RabbitMQ")
@@ -75,10 +115,46 @@ func NewRabbitProducerService(cfg *config.AppConfig, repo StorageProvider) (*Rab
 		return nil, err
 	}
 
-	publisher := wbrabbit.NewPublisher(ch, ex.Name())
+	// включаем publisher confirms: строка помечается как queued только после подтверждения брокером
+	if err = ch.Confirm(false); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to enable publisher confirms in RabbitMQ")
+		return nil, err
+	}
 
 	wbzlog.Logger.Info().Msg("Connected to RabbitMQ")
-	return &RabbitService{client: client, channel: ch, publisher: publisher, cfg: &cfg.RetrysConfig, repo: repo}, nil
+	return &RabbitService{
+		client:    client,
+		channel:   ch,
+		publisher: ch,
+		exchange:  ex.Name(),
+		cfg:       &cfg.RetrysConfig,
+		producer:  producerConfig(cfg.ProducerConfig),
+		repo:      repo,
+		cache:     cache,
+	}, nil
+}
+
+// producerConfig подставляет значения по умолчанию для незаданных параметров опроса.
+func producerConfig(cfg config.ProducerConfig) config.ProducerConfig {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 2 * time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = time.Minute
+	}
+	if cfg.ReaperInterval <= 0 {
+		cfg.ReaperInterval = 30 * time.Second
+	}
+	if cfg.ConfirmTimeout <= 0 {
+		cfg.ConfirmTimeout = 5 * time.Second
+	}
+	if cfg.ScheduleHorizon <= 0 {
+		cfg.ScheduleHorizon = time.Hour
+	}
+	return cfg
 }
 
 func (s *RabbitService) Close() error {
@@ -101,22 +177,39 @@ func (s *RabbitService) Close() error {
 	return nil
 }
 
-func (s *RabbitService) Publish(notification *app.Notification) error {
+// Publish публикует уведомление и дожидается подтверждения от брокера.
+func (s *RabbitService) Publish(ctx context.Context, notification *app.Notification) error {
 	body, err := json.Marshal(notification)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to marshal notification")
 		return err
 	}
-	err = s.publisher.PublishWithRetry(
-		body,
-		"notify",
-		"application/json",
-		retry.Strategy{
-			Attempts: s.cfg.Attempts,
-			Delay:    s.cfg.Delay,
-			Backoff:  s.cfg.Backoffs,
-		},
-	)
+	err = retry.Do(func() error {
+		confirmCtx, cancel := context.WithTimeout(ctx, s.producer.ConfirmTimeout)
+		defer cancel()
+
+		confirmation, err := s.publisher.PublishWithDeferredConfirmWithContext(confirmCtx, s.exchange, "notify", false, false, amqp091.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp091.Persistent,
+			MessageId:    notification.ID.String(),
+			Body:         body,
+		})
+		if err != nil {
+			return err
+		}
+		acked, err := confirmation.WaitContext(confirmCtx)
+		if err != nil {
+			return err
+		}
+		if !acked {
+			return fmt.Errorf("broker nacked notification %s", notification.ID)
+		}
+		return nil
+	}, retry.Strategy{
+		Attempts: s.cfg.Attempts,
+		Delay:    s.cfg.Delay,
+		Backoff:  s.cfg.Backoffs,
+	})
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to publish notification")
 		return err
@@ -133,10 +226,10 @@ func (s *RabbitService) Channel() *wbrabbit.Channel {
 	return s.channel
 }
 
+// UploadFromDB периодически захватывает готовые уведомления из БД и публикует их в RabbitMQ.
+// Раз в poll_interval перед захватом порождает уведомления из расписаний на schedule_horizon вперед.
 func (s *RabbitService) UploadFromDB(ctx context.Context) {
-	const batchSize = 100
-	lastID := "00000000-0000-0000-0000-000000000000" // минимальный UUID для первого запроса
-
+	var nextMaterialize time.Time
 	for {
 		select {
 		case <-ctx.Done():
@@ -145,34 +238,113 @@ func (s *RabbitService) UploadFromDB(ctx context.Context) {
 		default:
 		}
 
-		notifications, err := s.repo.GetNotifications(app.Pending, batchSize, lastID)
+		if now := time.Now(); !now.Before(nextMaterialize) {
+			s.materializeSchedules(now)
+			nextMaterialize = now.Add(s.producer.PollInterval)
+		}
+
+		notifications, err := s.repo.ClaimNotifications(s.producer.BatchSize, s.producer.Lease)
 		if err != nil {
-			wbzlog.Logger.Error().Err(err).Msg("Failed to get notifications from DB")
-			time.Sleep(2 * time.Second)
+			wbzlog.Logger.Error().Err(err).Msg("Failed to claim notifications from DB")
+			sleep(ctx, s.producer.PollInterval)
 			continue
 		}
 
 		if len(notifications) == 0 {
-			time.Sleep(2 * time.Second)
-			lastID = "00000000-0000-0000-0000-000000000000"
+			sleep(ctx, s.producer.PollInterval)
 			continue
 		}
-
 		for _, n := range notifications {
-			select {
-			case <-ctx.Done():
-				wbzlog.Logger.Info().Msg("Context canceled during publishing — exiting cleanly")
+			s.invalidate(n.ID.String())
+		}
+
+		for i, n := range notifications {
+			if ctx.Err() != nil {
+				wbzlog.Logger.Info().Msg("Context canceled during publishing — releasing claimed notifications")
+				s.release(notifications[i:])
 				return
-			default:
 			}
 
-			if err := s.Publish(n); err != nil {
-				wbzlog.Logger.Error().Err(err).Msg("Failed to publish notification")
+			if err := s.Publish(ctx, n); err != nil {
+				s.release(notifications[i : i+1])
+				continue
+			}
+
+			if err := s.repo.MarkNotificationQueued(n.ID.String()); err != nil {
+				if errors.Is(err, app.ErrStatusConflict) {
+					// консьюмер уже взял уведомление в работу
+					wbzlog.Logger.Debug().Str("id", n.ID.String()).Msg("Notification left processing before it was marked as queued")
+					continue
+				}
+				// сообщение уже в очереди; по истечении аренды строка вернется в pending,
+				// и консьюмер должен быть готов к повторной доставке
+				wbzlog.Logger.Error().Err(err).Str("id", n.ID.String()).Msg("Failed to mark notification as queued")
+				continue
+			}
+			s.invalidate(n.ID.String())
+		}
+	}
+}
+
+// RunLeaseReaper возвращает в pending уведомления с истекшей арендой.
+func (s *RabbitService) RunLeaseReaper(ctx context.Context) {
+	ticker := time.NewTicker(s.producer.ReaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wbzlog.Logger.Info().Msg("Graceful shutdown: stopping lease reaper")
+			return
+		case <-ticker.C:
+			reaped, err := s.repo.ReapExpiredLeases()
+			if err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("Failed to reap expired leases")
 				continue
 			}
+			for _, id := range reaped {
+				s.invalidate(id)
+			}
+			if len(reaped) > 0 {
+				wbzlog.Logger.Warn().Int("count", len(reaped)).Msg("Returned notifications with expired lease to pending")
+			}
+		}
+	}
+}
 
-			// обновляем lastID для следующего батча
-			lastID = n.ID.String()
+func (s *RabbitService) materializeSchedules(now time.Time) {
+	created, err := s.repo.MaterializeSchedules(now.Add(s.producer.ScheduleHorizon), s.producer.BatchSize, s.producer.BatchSize)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to materialize schedules")
+		return
+	}
+	if created > 0 {
+		wbzlog.Logger.Info().Int("count", created).Msg("Created notifications from schedules")
+	}
+}
+
+func (s *RabbitService) release(notifications []*app.Notification) {
+	for _, n := range notifications {
+		if err := s.repo.ReleaseNotification(n.ID.String()); err != nil {
+			if !errors.Is(err, app.ErrStatusConflict) {
+				wbzlog.Logger.Error().Err(err).Str("id", n.ID.String()).Msg("Failed to release notification")
+			}
+			continue
 		}
+		s.invalidate(n.ID.String())
+	}
+}
+
+// invalidate сбрасывает закэшированную копию уведомления после смены статуса в Postgres.
+func (s *RabbitService) invalidate(id string) {
+	if err := s.cache.DeleteNotification(id); err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", id).Msg("Failed to invalidate notification in cache")
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
 	}
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"github.com/mitchellh/mapstructure"
 	wbfconfig "github.com/wb-go/wbf/config"
 	"os"
 	"time"
@@ -15,8 +16,14 @@ type AppConfig struct {
 	DBConfig       dbConfig       `mapstructure:"db_config"`
 	TelegramConfig telegramConfig `mapstructure:"telegram"`
 	MailConfig     mailConfig     `mapstructure:"mail"`
-	RetrysConfig   RetrysConfig   `mapstructure:"retry_strategy"`
-	GinConfig      ginConfig      `mapstructure:"gin"`
+	// Channels — секции настроек подключаемых каналов доставки, ключ — имя канала (см. ChannelSection)
+	Channels       map[string]map[string]any `mapstructure:"channels"`
+	RetrysConfig   RetrysConfig              `mapstructure:"retry_strategy"`
+	DeliveryRetry  DeliveryRetry             `mapstructure:"delivery_retry"`
+	ConsumerConfig ConsumerConfig            `mapstructure:"consumer"`
+	ProducerConfig ProducerConfig            `mapstructure:"producer"`
+	Idempotency    Idempotency               `mapstructure:"idempotency"`
+	GinConfig      ginConfig                 `mapstructure:"gin"`
 }
 
 type RetrysConfig struct {
@@ -25,6 +32,42 @@ type RetrysConfig struct {
 	Backoffs float64       `mapstructure:"backoffs" default:"2"`
 }
 
+// DeliveryRetry задает политику повторной отправки уведомлений.
+// Channels переопределяет параметры по умолчанию для отдельных каналов.
+type DeliveryRetry struct {
+	RetrysConfig `mapstructure:",squash"`
+	MaxDelay     time.Duration           `mapstructure:"max_delay" default:"1h"`
+	Jitter       float64                 `mapstructure:"jitter" default:"0.2"`
+	Channels     map[string]RetrysConfig `mapstructure:"channels"`
+}
+
+// ConsumerConfig задает пулы воркеров консьюмера по каналам доставки.
+// Сумма воркеров и QueueSize всех пулов должна укладываться в rabbitmq.prefetch_count:
+// сообщения сверх prefetch брокер не выдаст, и лишние воркеры будут простаивать.
+type ConsumerConfig struct {
+	DefaultWorkers int            `mapstructure:"default_workers" default:"1"`
+	Workers        map[string]int `mapstructure:"workers"`
+	QueueSize      int            `mapstructure:"queue_size" default:"0"`
+}
+
+// ProducerConfig задает опрос БД продюсером. Lease — срок, на который строка захватывается
+// экземпляром сервиса; по его истечении реапер возвращает ее в pending.
+// ScheduleHorizon — на сколько вперед расписания порождают уведомления.
+type ProducerConfig struct {
+	BatchSize       int           `mapstructure:"batch_size" default:"100"`
+	PollInterval    time.Duration `mapstructure:"poll_interval" default:"2s"`
+	Lease           time.Duration `mapstructure:"lease" default:"1m"`
+	ReaperInterval  time.Duration `mapstructure:"reaper_interval" default:"30s"`
+	ConfirmTimeout  time.Duration `mapstructure:"confirm_timeout" default:"5s"`
+	ScheduleHorizon time.Duration `mapstructure:"schedule_horizon" default:"1h"`
+}
+
+// Idempotency задает, сколько хранится ключ Idempotency-Key и как часто удаляются просроченные ключи.
+type Idempotency struct {
+	Retention     time.Duration `mapstructure:"retention" default:"24h"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval" default:"1h"`
+}
+
 type ginConfig struct {
 	Mode string `mapstructure:"mode" default:"debug"`
 }
@@ -32,6 +75,8 @@ type ginConfig struct {
 type ServerConfig struct {
 	Host string `mapstructure:"host" default:"localhost"`
 	Port int    `mapstructure:"port" default:"8080"`
+	// DebugAddr — адрес отдельного служебного листенера с /debug/vars; пустой — листенер выключен
+	DebugAddr string `mapstructure:"debug_addr" default:""`
 }
 
 type loggerConfig struct {
@@ -45,15 +90,20 @@ type RabbitmqConfig struct {
 	Password  string `mapstructure:"password" default:"guest"`
 	Exchange  string `mapstructure:"exchange" default:"notifications"`
 	QueueName string `mapstructure:"queue_name" default:"notifications_queue"`
+	// PrefetchCount — сколько неподтвержденных сообщений консьюмер может держать одновременно.
+	PrefetchCount int `mapstructure:"prefetch_count" default:"10"`
+
+	DeadLetterExchange string `mapstructure:"dead_letter_exchange" default:"notifications_dlx"`
+	DeadLetterQueue    string `mapstructure:"dead_letter_queue" default:"notifications_dlq"`
 }
 
 type redisConfig struct {
-	Host      string `mapstructure:"host" default:"localhost"`
-	Port      int    `mapstructure:"port" default:"6379"`
-	Password  string `mapstructure:"password" default:""`
-	DB        int    `mapstructure:"db" default:"0"`
-	TTL       string `mapstructure:"ttl" default:"30s"`
-	CacheSize int    `mapstructure:"cache_size" default:"1000"`
+	Host      string        `mapstructure:"host" default:"localhost"`
+	Port      int           `mapstructure:"port" default:"6379"`
+	Password  string        `mapstructure:"password" default:""`
+	DB        int           `mapstructure:"db" default:"0"`
+	TTL       time.Duration `mapstructure:"ttl" default:"30s"`
+	CacheSize int           `mapstructure:"cache_size" default:"1000"`
 }
 
 type postgresConfig struct {
@@ -73,8 +123,16 @@ type dbConfig struct {
 	ConnMaxLifetime time.Duration    `mapstructure:"connMaxLifetime"`
 }
 
+// telegramConfig — бот и ограничения частоты отправки: Telegram допускает около 30 сообщений
+// в секунду на бота, одно сообщение в секунду в личный чат и 20 в минуту в группу.
 type telegramConfig struct {
-	BotToken string `mapstructure:"bot_token" default:""`
+	BotToken      string        `mapstructure:"bot_token" default:""`
+	GlobalRate    float64       `mapstructure:"global_rate" default:"30"`
+	ChatInterval  time.Duration `mapstructure:"chat_interval" default:"1s"`
+	GroupInterval time.Duration `mapstructure:"group_interval" default:"3s"`
+	// MaxWait — дольше этого отправка не ждет своей очереди, а откладывается как временная ошибка;
+	// больше 1s не бывает, чтобы ожидание не занимало воркер консьюмера
+	MaxWait time.Duration `mapstructure:"max_wait" default:"1s"`
 }
 
 type mailConfig struct {
@@ -82,6 +140,19 @@ type mailConfig struct {
 	SMTPPort     int    `mapstructure:"smtp_port" default:"587"`
 	SMTPEmail    string `mapstructure:"smtp_user" default:""`
 	SMTPPassword string `mapstructure:"smtp_password" default:""`
+	// From — адрес отправителя в заголовке From (по умолчанию smtp_user), FromName — имя отправителя
+	From     string `mapstructure:"from" default:""`
+	FromName string `mapstructure:"from_name" default:""`
+	// TLS — starttls, implicit (порт 465) или none; Auth — plain, login, cram-md5 или none
+	TLS  string `mapstructure:"tls" default:""`
+	Auth string `mapstructure:"auth" default:"plain"`
+	// пул соединений: см. sender.SMTPConfig
+	PoolSize            int           `mapstructure:"pool_size" default:"4"`
+	MaxMessagesPerConn  int           `mapstructure:"max_messages_per_conn" default:"100"`
+	IdleTimeout         time.Duration `mapstructure:"idle_timeout" default:"5m"`
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval" default:"30s"`
+	DialTimeout         time.Duration `mapstructure:"dial_timeout" default:"10s"`
+	Timeout             time.Duration `mapstructure:"timeout" default:"1m"`
 }
 
 func NewAppConfig() (*AppConfig, error) {
@@ -123,3 +194,24 @@ func NewAppConfig() (*AppConfig, error) {
 
 	return &appCfg, nil
 }
+
+// ChannelSection раскладывает секцию channels.<name> в dst так же, как остальной конфиг
+// (теги mapstructure, длительности строками вида "10s"). Возвращает false, если секции нет.
+func (c *AppConfig) ChannelSection(name string, dst any) (bool, error) {
+	section, ok := c.Channels[name]
+	if !ok {
+		return false, nil
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           dst,
+	})
+	if err != nil {
+		return true, err
+	}
+	if err := decoder.Decode(section); err != nil {
+		return true, fmt.Errorf("channels.%s: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,114 @@
+package consumer
+
+import (
+	"context"
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"github.com/rabbitmq/amqp091-go"
+	wbrabbit "github.com/wb-go/wbf/rabbitmq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+// DeadLetterConsumer вычитывает dead-letter очередь и сохраняет сообщения в БД,
+// откуда их можно просмотреть и переотправить через API.
+type DeadLetterConsumer struct {
+	channel *wbrabbit.Channel
+	config  wbrabbit.ConsumerConfig
+	cfg     *config.RetrysConfig
+	repo    DeadLetterStorage
+}
+
+type DeadLetterStorage interface {
+	SaveDeadLetter(dl *app.DeadLetter) error
+}
+
+func NewDeadLetterConsumer(cfg *config.AppConfig, repo DeadLetterStorage) (*DeadLetterConsumer, error) {
+	ch, err := openChannel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// без QoS брокер отдает всю dead-letter очередь разом, и при недоступной БД она
+	// крутится в памяти консьюмера
+	if err := ch.Qos(prefetchCount(cfg), 0, false); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to set QoS for dead-letter consumer")
+		return nil, err
+	}
+
+	return &DeadLetterConsumer{
+		channel: ch,
+		config:  wbrabbit.ConsumerConfig{Queue: cfg.RabbitmqConfig.DeadLetterQueue},
+		cfg:     &cfg.RetrysConfig,
+		repo:    repo,
+	}, nil
+}
+
+func (c *DeadLetterConsumer) Start(ctx context.Context) {
+	deliveries, err := consume(c.channel, c.config, retry.Strategy{Attempts: c.cfg.Attempts, Delay: c.cfg.Delay, Backoff: c.cfg.Backoffs})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to consume dead-letter queue")
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			wbzlog.Logger.Info().Msg("Dead-letter consumer stopped by context cancel")
+			return
+
+		case d, ok := <-deliveries:
+			if !ok {
+				wbzlog.Logger.Info().Msg("Dead-letter channel closed, exiting consumer loop")
+				return
+			}
+
+			reason, count := deathInfo(d)
+			dl := app.NewDeadLetter(d.Body, reason, count)
+			if err := c.repo.SaveDeadLetter(dl); err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("Failed to save dead letter, requeueing")
+				// пауза перед возвратом, чтобы не крутить сообщение, пока БД недоступна;
+				// остановка сервиса ее прерывает, и сообщение возвращается в очередь сразу
+				select {
+				case <-time.After(c.cfg.Delay):
+				case <-ctx.Done():
+				}
+				if err := d.Nack(false, true); err != nil {
+					wbzlog.Logger.Error().Err(err).Msg("Failed to nack dead letter")
+				}
+				continue
+			}
+
+			if err := d.Ack(false); err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("Failed to ack dead letter")
+			}
+
+			wbzlog.Logger.Warn().
+				Str("dead_letter_id", dl.ID.String()).
+				Str("reason", dl.Reason).
+				Msg("Dead letter stored")
+		}
+	}
+}
+
+// deathInfo достает причину и число "смертей" сообщения из заголовка x-death.
+func deathInfo(d amqp091.Delivery) (string, int64) {
+	reason, _ := d.Headers["x-first-death-reason"].(string)
+	if reason == "" {
+		reason = "unknown"
+	}
+
+	var count int64
+	deaths, _ := d.Headers["x-death"].([]interface{})
+	for _, death := range deaths {
+		table, ok := death.(amqp091.Table)
+		if !ok {
+			continue
+		}
+		if c, ok := table["count"].(int64); ok {
+			count += c
+		}
+	}
+	return reason, count
+}
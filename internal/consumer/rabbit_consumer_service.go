@@ -6,23 +6,39 @@ import (
 	"delayedNotifier/internal/config"
 	"delayedNotifier/internal/sender"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
 	wbrabbit "github.com/wb-go/wbf/rabbitmq"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
+	"slices"
 	"sync"
+	"time"
 )
 
 type RabbitConsumerService struct {
-	consumer *wbrabbit.Consumer
+	channel  *wbrabbit.Channel
+	config   wbrabbit.ConsumerConfig
 	cfg      *config.RetrysConfig
 	repo     StorageProvider
 	cache    CacheProvider
 	sender   map[app.ChannelType]sender.Sender
+	policies map[app.ChannelType]app.RetryPolicy
+	policy   app.RetryPolicy
+	workers  config.ConsumerConfig
+	prefetch int
 }
 
 type StorageProvider interface {
-	UpdateNotificationStatus(id string, status app.StatusType) error
+	GetNotification(id string) (*app.Notification, error)
+	TransitionNotification(id string, to app.StatusType, from ...app.StatusType) error
+	UpdateDeliveryState(notification *app.Notification, expected app.StatusType) error
+	GetQuietHours(recipient string, channel app.ChannelType) (*app.QuietHours, error)
+	GetContact(id string) (*app.Contact, error)
+	UpdateNotificationRoute(notification *app.Notification) error
+	SaveDeliveryAttempt(attempt *app.DeliveryAttempt) error
 }
 
 type CacheProvider interface {
@@ -32,13 +48,91 @@ type CacheProvider interface {
 func NewConsumer(cfg *config.AppConfig, sender *sender.SenderRegistry, repo StorageProvider, cache CacheProvider) (*RabbitConsumerService, error) {
 	config := wbrabbit.ConsumerConfig{
 		Queue:     cfg.RabbitmqConfig.QueueName,
-		Consumer:  "",
+		Consumer:  "delayed-notifier-" + uuid.NewString(), // явный тег нужен, чтобы отменить подписку при остановке
 		AutoAck:   false,
 		Exclusive: false,
 		NoLocal:   false,
 		NoWait:    false,
 		Args:      nil,
 	}
+	ch, err := openChannel(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// ограничиваем число неподтвержденных сообщений, чтобы один консьюмер не забирал всю очередь
+	prefetch := prefetchCount(cfg)
+	if err := ch.Qos(prefetch, 0, false); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to set QoS in RabbitMQ")
+		return nil, err
+	}
+
+	policies := make(map[app.ChannelType]app.RetryPolicy, len(cfg.DeliveryRetry.Channels))
+	for channel, override := range cfg.DeliveryRetry.Channels {
+		policies[app.ChannelType(channel)] = newRetryPolicy(cfg.DeliveryRetry, override)
+	}
+
+	return &RabbitConsumerService{
+		channel:  ch,
+		config:   config,
+		cfg:      &cfg.RetrysConfig,
+		sender:   sender.All(),
+		repo:     repo,
+		cache:    cache,
+		policies: policies,
+		policy:   newRetryPolicy(cfg.DeliveryRetry, cfg.DeliveryRetry.RetrysConfig),
+		workers:  cfg.ConsumerConfig,
+		prefetch: prefetch,
+	}, nil
+}
+
+func prefetchCount(cfg *config.AppConfig) int {
+	if cfg.RabbitmqConfig.PrefetchCount > 0 {
+		return cfg.RabbitmqConfig.PrefetchCount
+	}
+	return 1
+}
+
+func (c *RabbitConsumerService) workersFor(channel app.ChannelType) int {
+	if n, ok := c.workers.Workers[string(channel)]; ok && n > 0 {
+		return n
+	}
+	return c.workers.DefaultWorkers
+}
+
+// newRetryPolicy собирает политику из общих настроек, незаданные поля override берутся из них.
+func newRetryPolicy(base config.DeliveryRetry, override config.RetrysConfig) app.RetryPolicy {
+	policy := app.RetryPolicy{
+		MaxAttempts: base.Attempts,
+		Delay:       base.Delay,
+		Multiplier:  base.Backoffs,
+		MaxDelay:    base.MaxDelay,
+		Jitter:      base.Jitter,
+	}
+	if override.Attempts > 0 {
+		policy.MaxAttempts = override.Attempts
+	}
+	if override.Delay > 0 {
+		policy.Delay = override.Delay
+	}
+	if override.Backoffs > 0 {
+		policy.Multiplier = override.Backoffs
+	}
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	return policy
+}
+
+func (c *RabbitConsumerService) policyFor(channel app.ChannelType) app.RetryPolicy {
+	if policy, ok := c.policies[channel]; ok {
+		return policy
+	}
+	return c.policy
+}
+
+// openChannel подключается к RabbitMQ и открывает канал для консьюмера.
+func openChannel(cfg *config.AppConfig) (*wbrabbit.Channel, error) {
 	rabbitDSN := fmt.Sprintf(
 		"amqp://%s:%s@%s:%d/",
 		cfg.RabbitmqConfig.User,
@@ -57,113 +151,457 @@ func NewConsumer(cfg *config.AppConfig, sender *sender.SenderRegistry, repo Stor
 		wbzlog.Logger.Error().Err(err).Msg("Failed to open channel in RabbitMQ")
 		return nil, err
 	}
+	return ch, nil
+}
 
-	return &RabbitConsumerService{consumer: wbrabbit.NewConsumer(ch, &config), cfg: &cfg.RetrysConfig, sender: sender.All(), repo: repo, cache: cache}, nil
+// consume подписывается на очередь с ручным подтверждением сообщений.
+func consume(ch *wbrabbit.Channel, cfg wbrabbit.ConsumerConfig, strategy retry.Strategy) (<-chan amqp091.Delivery, error) {
+	var deliveries <-chan amqp091.Delivery
+	err := retry.Do(func() error {
+		d, err := ch.Consume(cfg.Queue, cfg.Consumer, cfg.AutoAck, cfg.Exclusive, cfg.NoLocal, cfg.NoWait, cfg.Args)
+		if err != nil {
+			return err
+		}
+		deliveries = d
+		return nil
+	}, strategy)
+	return deliveries, err
 }
 
+// Start читает очередь и раздает сообщения пулам воркеров по каналам доставки.
+// При отмене ctx подписка снимается, невзятые задачи возвращаются в очередь,
+// а Start возвращается только после завершения уже начатых отправок.
 func (c *RabbitConsumerService) Start(ctx context.Context) {
+	deliveries, err := consume(c.channel, c.config, retry.Strategy{Attempts: c.cfg.Attempts, Delay: c.cfg.Delay, Backoff: c.cfg.Backoffs})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to Consume RabbitMQ")
+		return
+	}
+
 	var wg sync.WaitGroup
-	msgChan := make(chan []byte)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer close(msgChan)
-		err := c.consumer.ConsumeWithRetry(msgChan, retry.Strategy{Attempts: c.cfg.Attempts, Delay: c.cfg.Delay, Backoff: c.cfg.Backoffs})
-		if err != nil {
-			wbzlog.Logger.Debug().Msg("Failed to Consume RabbitMQ")
+	pools := make(map[app.ChannelType]*workerPool, len(c.sender))
+	for channel := range c.sender {
+		pool := newWorkerPool(channel, c.workersFor(channel), c.workers.QueueSize)
+		pool.start(ctx, &wg, c.handleJob)
+		pools[channel] = pool
+	}
+	c.checkPrefetch(pools)
+
+	defer func() {
+		if err := c.channel.Cancel(c.config.Consumer, false); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to cancel RabbitMQ consumer")
 		}
+		for _, pool := range pools {
+			close(pool.jobs)
+		}
+		wg.Wait()
+		wbzlog.Logger.Info().Msg("Consumer workers drained")
 	}()
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		for {
-			select {
-			case <-ctx.Done():
-				wbzlog.Logger.Info().Msg("Consumer stopped by context cancel")
+	for {
+		select {
+		case <-ctx.Done():
+			wbzlog.Logger.Info().Msg("Consumer stopped by context cancel")
+			return
+
+		case d, ok := <-deliveries:
+			if !ok {
+				wbzlog.Logger.Info().Msg("Message channel closed, exiting consumer loop")
 				return
+			}
+			c.dispatch(ctx, pools, d)
+		}
+	}
+}
+
+// dispatch передает сообщение пулу его канала; если пул занят, ждет свободного места.
+func (c *RabbitConsumerService) dispatch(ctx context.Context, pools map[app.ChannelType]*workerPool, d amqp091.Delivery) {
+	var notif app.Notification
+	if err := json.Unmarshal(d.Body, &notif); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to unmarshal notification, moving to dead-letter queue")
+		c.settle(d, deadLetter)
+		return
+	}
+
+	pool, ok := pools[notif.Channel]
+	if !ok {
+		// для неизвестного канала воркеров нет, process сразу пометит уведомление как failed
+		c.settle(d, c.process(&notif, d.Redelivered))
+		return
+	}
+
+	select {
+	case pool.jobs <- job{delivery: d, notif: &notif}:
+	case <-ctx.Done():
+		if err := d.Nack(false, true); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to requeue message on shutdown")
+		}
+	}
+}
+
+// checkPrefetch предупреждает, если пулы могут взять больше сообщений, чем выдаст брокер.
+func (c *RabbitConsumerService) checkPrefetch(pools map[app.ChannelType]*workerPool) {
+	capacity := 0
+	for _, pool := range pools {
+		capacity += pool.size + cap(pool.jobs)
+	}
+	if capacity > c.prefetch {
+		wbzlog.Logger.Warn().
+			Int("capacity", capacity).
+			Int("prefetch_count", c.prefetch).
+			Msg("Consumer workers and queues exceed prefetch_count, some workers will stay idle")
+	}
+}
+
+func (c *RabbitConsumerService) handleJob(j job) {
+	c.settle(j.delivery, c.process(j.notif, j.delivery.Redelivered))
+}
+
+// outcome определяет, как подтвердить сообщение после обработки.
+type outcome int
 
-			case body, ok := <-msgChan:
-				if !ok {
-					wbzlog.Logger.Info().Msg("Message channel closed, exiting consumer loop")
-					return
-				}
-
-				var notif app.Notification
-				if err := json.Unmarshal(body, &notif); err != nil {
-					wbzlog.Logger.Error().Err(err).Msg("Failed to unmarshal notification")
-					continue
-				}
-
-				wbzlog.Logger.Info().
-					Str("id", notif.ID.String()).
-					Str("channel", string(notif.Channel)).
-					Msg("Received notification from queue")
-
-				s, ok := c.sender[notif.Channel]
-				if !ok {
-					wbzlog.Logger.Error().
-						Str("channel", string(notif.Channel)).
-						Msg("Unknown notification channel")
-
-					if err := c.repo.UpdateNotificationStatus(notif.ID.String(), app.Failed); err != nil {
-						wbzlog.Logger.Error().
-							Err(err).
-							Str("id", notif.ID.String()).
-							Msg("Failed to update notification status to FAILED in DB")
-					}
-
-					notif.MarkAsFailed()
-
-					if err := c.cache.SaveNotification(&notif); err != nil {
-						wbzlog.Logger.Error().
-							Err(err).
-							Str("id", notif.ID.String()).
-							Msg("Failed to update notification in cache (FAILED)")
-					}
-					continue
-				}
-
-				if err := s.Send(&notif); err != nil {
-					wbzlog.Logger.Error().
-						Err(err).
-						Str("id", notif.ID.String()).
-						Msg("Failed to send notification")
-
-					if err := c.repo.UpdateNotificationStatus(notif.ID.String(), app.Failed); err != nil {
-						wbzlog.Logger.Error().
-							Err(err).
-							Str("id", notif.ID.String()).
-							Msg("Failed to update notification status to FAILED in DB")
-					}
-
-					notif.MarkAsFailed()
-
-					if err := c.cache.SaveNotification(&notif); err != nil {
-						wbzlog.Logger.Error().
-							Err(err).
-							Str("id", notif.ID.String()).
-							Msg("Failed to update notification in cache (FAILED)")
-					}
-					continue
-				}
-
-				if err := c.repo.UpdateNotificationStatus(notif.ID.String(), app.Sent); err != nil {
-					wbzlog.Logger.Error().Err(err).Msg("Failed to update notification status to SENT in DB")
-				}
-
-				notif.MarkAsSent()
-
-				if err := c.cache.SaveNotification(&notif); err != nil {
-					wbzlog.Logger.Error().Err(err).Msg("Failed to save notification to cache (SENT)")
-				}
-
-				wbzlog.Logger.Info().
-					Str("id", notif.ID.String()).
-					Msg("Notification successfully sent")
+const (
+	ack        outcome = iota // результат сохранен в БД
+	requeue                   // временная ошибка, сообщение нужно вернуть в очередь
+	deadLetter                // сообщение не может быть обработано, уходит в DLX
+)
+
+// settle подтверждает или отклоняет сообщение в соответствии с результатом обработки.
+func (c *RabbitConsumerService) settle(d amqp091.Delivery, result outcome) {
+	var err error
+	switch result {
+	case ack:
+		err = d.Ack(false)
+	case requeue:
+		// даем БД время восстановиться, чтобы не крутить сообщение в горячем цикле. Nack откладывается
+		// таймером, а не сном: воркер сразу берет следующую задачу, сообщение до возврата занимает
+		// место в prefetch.
+		time.AfterFunc(c.cfg.Delay, func() {
+			if err := d.Nack(false, true); err != nil {
+				wbzlog.Logger.Error().Err(err).Uint64("delivery_tag", d.DeliveryTag).Msg("Failed to requeue message")
 			}
+		})
+	case deadLetter:
+		err = d.Nack(false, false)
+	}
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Uint64("delivery_tag", d.DeliveryTag).Msg("Failed to settle message")
+	}
+}
+
+// process отправляет уведомление и сохраняет результат в БД.
+// Сообщение подтверждается только после того, как новый статус записан в Postgres.
+func (c *RabbitConsumerService) process(notif *app.Notification, redelivered bool) outcome {
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Str("channel", string(notif.Channel)).
+		Int("attempts", notif.Attempts).
+		Msg("Received notification from queue")
+
+	if result, ok := c.beginSending(notif, redelivered); !ok {
+		return result
+	}
+
+	if result, ok := c.routeToContact(notif); !ok {
+		return result
+	}
+
+	if result, deferred := c.deferForQuietHours(notif); deferred {
+		return result
+	}
+
+	s, ok := c.sender[notif.Channel]
+	if !ok {
+		wbzlog.Logger.Error().
+			Str("channel", string(notif.Channel)).
+			Msg("Unknown notification channel")
+
+		// неизвестный канал не исправится повторной попыткой, остается только запасной
+		notif.LastError = fmt.Sprintf("unknown channel %q", notif.Channel)
+		return c.fallBack(notif)
+	}
+
+	err := s.Send(notif)
+	c.recordAttempt(notif, err)
+	if err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to send notification")
+
+		return c.handleSendFailure(notif, err)
+	}
+
+	notif.MarkAsSent()
+	if err := c.repo.UpdateDeliveryState(notif, app.Sending); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result
 		}
-	}()
+		wbzlog.Logger.Error().Err(err).Msg("Failed to update notification status to SENT in DB")
+		return requeue
+	}
+
+	if err := c.cache.SaveNotification(notif); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to save notification to cache (SENT)")
+	}
+
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Str("channel", string(notif.Channel)).
+		Msg("Notification successfully sent")
+	return ack
+}
+
+// recordAttempt сохраняет попытку отправки в историю уведомления. История вспомогательная:
+// ошибка записи только логируется и не влияет на доставку.
+func (c *RabbitConsumerService) recordAttempt(notif *app.Notification, sendErr error) {
+	if err := c.repo.SaveDeliveryAttempt(app.NewDeliveryAttempt(notif, sendErr)); err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to save delivery attempt")
+	}
+}
+
+// beginSending перепроверяет статус в Postgres и переводит уведомление в sending.
+// Если статус уже не queued/processing (уведомление отменено, отправлено или переназначено),
+// сообщение подтверждается без отправки.
+func (c *RabbitConsumerService) beginSending(notif *app.Notification, redelivered bool) (outcome, bool) {
+	id := notif.ID.String()
+	err := c.repo.TransitionNotification(id, app.Sending, app.Queued, app.Processing)
+	if err == nil {
+		notif.Status = app.Sending
+		return ack, true
+	}
+	if !errors.Is(err, app.ErrStatusConflict) {
+		wbzlog.Logger.Error().Err(err).Str("id", id).Msg("Failed to update notification status to SENDING in DB")
+		return requeue, false
+	}
+
+	current, err := c.repo.GetNotification(id)
+	if err != nil {
+		return requeue, false
+	}
+	if current == nil {
+		wbzlog.Logger.Info().Str("id", id).Msg("Notification was deleted, dropping message")
+		return ack, false
+	}
+	// сообщение вернулось в очередь после падения посреди отправки — продолжаем с того же места
+	if current.Status == app.Sending && redelivered {
+		notif.Status = app.Sending
+		return ack, true
+	}
 
-	wg.Wait()
+	if current.Status == app.Canceled {
+		wbzlog.Logger.Info().Str("id", id).Msg("Notification was canceled, dropping message")
+	} else {
+		wbzlog.Logger.Info().
+			Str("id", id).
+			Str("status", string(current.Status)).
+			Msg("Notification is no longer queued, dropping message")
+	}
+	if err := c.cache.SaveNotification(current); err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", id).Msg("Failed to refresh notification in cache")
+	}
+	return ack, false
+}
+
+// routeToContact заново выбирает канал, адрес и запасные каналы уведомления контакта по его текущим
+// предпочтениям среди каналов, у которых есть отправитель и которые принимают содержимое уведомления. Если контакт удален, уведомление уходит
+// по последнему выбранному адресу. Уже начатая цепочка запасных каналов не пересобирается.
+func (c *RabbitConsumerService) routeToContact(notif *app.Notification) (outcome, bool) {
+	if notif.ContactID == nil || notif.FallbackStep > 0 {
+		return ack, true
+	}
+	contact, err := c.repo.GetContact(notif.ContactID.String())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to load notification contact")
+		return requeue, false
+	}
+	if contact == nil {
+		return ack, true
+	}
+
+	route := contact.Route(notif.Message, func(channel app.ChannelType) bool {
+		_, ok := c.sender[channel]
+		return ok && notif.SupportsContent(channel)
+	})
+	if len(route) == 0 {
+		notif.LastError = fmt.Sprintf("contact %s has no available channel for this message", contact.ID)
+		return c.markFailed(notif), false
+	}
+	channel, fallbacks := route[0].Channel, route[1:]
+	if channel == notif.Channel && route[0].Recipient == notif.Recipient && slices.Equal(fallbacks, notif.Fallbacks) {
+		return ack, true
+	}
+
+	notif.Channel, notif.Recipient, notif.Fallbacks = channel, route[0].Recipient, fallbacks
+	notif.UpdatedAt = time.Now()
+	if err := c.repo.UpdateNotificationRoute(notif); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result, false
+		}
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to save notification route in DB")
+		return requeue, false
+	}
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Str("channel", string(channel)).
+		Msg("Notification routed by contact preferences")
+	return ack, true
+}
+
+// deferForQuietHours откладывает уведомление до конца тихих часов получателя, не тратя попытку.
+func (c *RabbitConsumerService) deferForQuietHours(notif *app.Notification) (outcome, bool) {
+	quiet, err := c.repo.GetQuietHours(notif.Recipient, notif.Channel)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to load quiet hours")
+		return requeue, true
+	}
+	if quiet == nil {
+		return ack, false
+	}
+	now := time.Now()
+	allowed := quiet.NextAllowed(now)
+	if !allowed.After(now) {
+		return ack, false
+	}
+
+	notif.DeferUntil(allowed)
+	if err := c.repo.UpdateDeliveryState(notif, app.Sending); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result, true
+		}
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to defer notification in DB")
+		return requeue, true
+	}
+	if err := c.cache.SaveNotification(notif); err != nil {
+		wbzlog.Logger.Error().Err(err).Str("id", notif.ID.String()).Msg("Failed to update notification in cache (PENDING)")
+	}
+
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Time("next_attempt_at", allowed).
+		Msg("Notification deferred until the end of recipient quiet hours")
+	return ack, true
+}
+
+// conflict обрабатывает app.ErrStatusConflict при сохранении результата:
+// статус уже изменил кто-то другой, поэтому сообщение просто подтверждается.
+func (c *RabbitConsumerService) conflict(notif *app.Notification, err error) (outcome, bool) {
+	if !errors.Is(err, app.ErrStatusConflict) {
+		return 0, false
+	}
+	wbzlog.Logger.Warn().
+		Str("id", notif.ID.String()).
+		Msg("Notification status changed concurrently, result not saved")
+	return ack, true
+}
+
+// handleSendFailure планирует повторную отправку с экспоненциальной задержкой (но не раньше
+// Retry-After из sender.RetryableError). Если попытки исчерпаны или ошибка постоянная
+// (sender.PermanentError), уведомление переходит на следующий запасной канал, а без него — в failed.
+func (c *RabbitConsumerService) handleSendFailure(notif *app.Notification, sendErr error) outcome {
+	policy := c.policyFor(notif.Channel)
+	notif.Attempts++
+	notif.LastError = sendErr.Error()
+
+	if sender.IsPermanent(sendErr) {
+		wbzlog.Logger.Warn().
+			Err(sendErr).
+			Str("id", notif.ID.String()).
+			Msg("Permanent delivery error, not retrying")
+		return c.fallBack(notif)
+	}
+	if policy.Exhausted(notif.Attempts) {
+		wbzlog.Logger.Warn().
+			Str("id", notif.ID.String()).
+			Int("attempts", notif.Attempts).
+			Msg("Retry attempts exhausted")
+		return c.fallBack(notif)
+	}
+
+	// получатель мог сам попросить подождать (rate limit) — раньше этого срока повторять бессмысленно
+	retryAfter, _ := sender.RetryAfter(sendErr)
+	notif.MarkForRetry(policy.NextAttemptNotBefore(time.Now(), notif.Attempts, retryAfter), sendErr.Error())
+
+	if err := c.repo.UpdateDeliveryState(notif, app.Sending); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result
+		}
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to reschedule notification in DB")
+		return requeue
+	}
+
+	if err := c.cache.SaveNotification(notif); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to update notification in cache (PENDING)")
+	}
+
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Int("attempts", notif.Attempts).
+		Time("next_attempt_at", *notif.NextAttemptAt).
+		Msg("Notification rescheduled for retry")
+	return ack
+}
+
+// fallBack переключает уведомление на следующий запасной канал и возвращает его в pending для
+// немедленной отправки; если запасных каналов не осталось, уведомление помечается как failed.
+func (c *RabbitConsumerService) fallBack(notif *app.Notification) outcome {
+	failed := notif.Channel
+	if !notif.AdvanceFallback(notif.LastError) {
+		return c.markFailed(notif)
+	}
+
+	if err := c.repo.UpdateDeliveryState(notif, app.Sending); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result
+		}
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to switch notification to fallback channel in DB")
+		return requeue
+	}
+
+	if err := c.cache.SaveNotification(notif); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to update notification in cache (PENDING)")
+	}
+
+	wbzlog.Logger.Info().
+		Str("id", notif.ID.String()).
+		Str("failed_channel", string(failed)).
+		Str("channel", string(notif.Channel)).
+		Int("fallback_step", notif.FallbackStep).
+		Msg("Notification switched to fallback channel")
+	return ack
+}
+
+func (c *RabbitConsumerService) markFailed(notif *app.Notification) outcome {
+	notif.MarkAsFailed()
+
+	if err := c.repo.UpdateDeliveryState(notif, app.Sending); err != nil {
+		if result, handled := c.conflict(notif, err); handled {
+			return result
+		}
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to update notification status to FAILED in DB")
+		return requeue
+	}
+
+	if err := c.cache.SaveNotification(notif); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notif.ID.String()).
+			Msg("Failed to update notification in cache (FAILED)")
+	}
+	return deadLetter
 }
@@ -0,0 +1,178 @@
+package consumer
+
+import (
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/sender"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// fakeSender возвращает ошибки из errs по очереди, а после них отправляет успешно.
+type fakeSender struct {
+	errs []error
+	sent int
+}
+
+func (s *fakeSender) Send(*app.Notification) error {
+	s.sent++
+	if len(s.errs) == 0 {
+		return nil
+	}
+	err := s.errs[0]
+	s.errs = s.errs[1:]
+	return err
+}
+
+// fakeStore хранит состояние уведомления так, как его сохранил бы консьюмер, и историю попыток.
+type fakeStore struct {
+	saved    *app.Notification
+	attempts []*app.DeliveryAttempt
+}
+
+func (s *fakeStore) GetNotification(string) (*app.Notification, error) { return s.saved, nil }
+
+func (s *fakeStore) TransitionNotification(string, app.StatusType, ...app.StatusType) error {
+	return nil
+}
+
+func (s *fakeStore) UpdateDeliveryState(notification *app.Notification, _ app.StatusType) error {
+	saved := *notification
+	s.saved = &saved
+	return nil
+}
+
+func (s *fakeStore) GetQuietHours(string, app.ChannelType) (*app.QuietHours, error) { return nil, nil }
+
+func (s *fakeStore) GetContact(string) (*app.Contact, error) { return nil, nil }
+
+func (s *fakeStore) UpdateNotificationRoute(*app.Notification) error { return nil }
+
+func (s *fakeStore) SaveDeliveryAttempt(attempt *app.DeliveryAttempt) error {
+	s.attempts = append(s.attempts, attempt)
+	return nil
+}
+
+type fakeCache struct{}
+
+func (fakeCache) SaveNotification(*app.Notification) error { return nil }
+
+func newTestConsumer(store *fakeStore, senders map[app.ChannelType]sender.Sender) *RabbitConsumerService {
+	return &RabbitConsumerService{
+		repo:   store,
+		cache:  fakeCache{},
+		sender: senders,
+		policy: app.RetryPolicy{MaxAttempts: 3, Delay: time.Second, Multiplier: 2, MaxDelay: time.Minute},
+	}
+}
+
+func newTestNotification() *app.Notification {
+	return &app.Notification{
+		ID:        uuid.New(),
+		Channel:   app.Email,
+		Recipient: "user@example.com",
+		Message:   "Hi",
+		Status:    app.Queued,
+	}
+}
+
+func TestProcessRetriesUntilExhausted(t *testing.T) {
+	temporary := errors.New("connection reset")
+	email := &fakeSender{errs: []error{temporary, temporary, temporary}}
+	store := &fakeStore{}
+	c := newTestConsumer(store, map[app.ChannelType]sender.Sender{app.Email: email})
+
+	notif := newTestNotification()
+	var next time.Time
+	for attempt := 1; attempt < 3; attempt++ {
+		assert.Equal(t, ack, c.process(notif, false))
+		assert.Equal(t, app.Pending, store.saved.Status)
+		assert.Equal(t, attempt, store.saved.Attempts)
+		assert.Equal(t, "connection reset", store.saved.LastError)
+		// задержка растет с каждой попыткой
+		assert.True(t, store.saved.NextAttemptAt.After(next))
+		next = *store.saved.NextAttemptAt
+	}
+
+	assert.Equal(t, deadLetter, c.process(notif, false))
+	assert.Equal(t, app.Failed, store.saved.Status)
+	assert.Equal(t, 3, store.saved.Attempts)
+	assert.Equal(t, 3, email.sent)
+}
+
+func TestProcessPermanentErrorSkipsRetries(t *testing.T) {
+	email := &fakeSender{errs: []error{&sender.PermanentError{Err: sender.ErrRecipientUnreachable}}}
+	store := &fakeStore{}
+	c := newTestConsumer(store, map[app.ChannelType]sender.Sender{app.Email: email})
+
+	assert.Equal(t, deadLetter, c.process(newTestNotification(), false))
+	assert.Equal(t, 1, email.sent)
+	assert.Equal(t, app.Failed, store.saved.Status)
+	assert.Empty(t, store.saved.DeliveredChannel)
+}
+
+func TestProcessRetriesThenFallsBack(t *testing.T) {
+	temporary := errors.New("connection reset")
+	email := &fakeSender{errs: []error{temporary, temporary, temporary}}
+	telegram := &fakeSender{}
+	store := &fakeStore{}
+	c := newTestConsumer(store, map[app.ChannelType]sender.Sender{app.Email: email, app.Telegram: telegram})
+
+	notif := newTestNotification()
+	notif.Fallbacks = []app.Destination{{Channel: app.Telegram, Recipient: "123"}}
+	for attempt := 1; attempt < 3; attempt++ {
+		assert.Equal(t, ack, c.process(notif, false))
+		assert.Equal(t, app.Email, store.saved.Channel)
+	}
+
+	// третья неудача исчерпывает попытки: уведомление переходит на запасной канал
+	assert.Equal(t, ack, c.process(notif, false))
+	assert.Equal(t, app.Pending, store.saved.Status)
+	assert.Equal(t, app.Telegram, store.saved.Channel)
+	assert.Equal(t, "123", store.saved.Recipient)
+	assert.Equal(t, 1, store.saved.FallbackStep)
+	assert.Equal(t, 0, store.saved.Attempts)
+
+	assert.Equal(t, ack, c.process(notif, false))
+	assert.Equal(t, app.Sent, store.saved.Status)
+	assert.Equal(t, app.Telegram, store.saved.DeliveredChannel)
+	assert.Equal(t, 3, email.sent)
+	assert.Equal(t, 1, telegram.sent)
+}
+
+func TestProcessPermanentErrorFallsBackImmediately(t *testing.T) {
+	email := &fakeSender{errs: []error{&sender.PermanentError{Err: sender.ErrRecipientUnreachable}}}
+	store := &fakeStore{}
+	c := newTestConsumer(store, map[app.ChannelType]sender.Sender{app.Email: email, app.Telegram: &fakeSender{}})
+
+	notif := newTestNotification()
+	notif.Fallbacks = []app.Destination{{Channel: app.Telegram, Recipient: "123"}}
+	assert.Equal(t, ack, c.process(notif, false))
+	assert.Equal(t, 1, email.sent)
+	assert.Equal(t, app.Telegram, store.saved.Channel)
+	assert.Equal(t, 1, store.saved.FallbackStep)
+	assert.Equal(t, app.Email, store.saved.Primary.Channel)
+}
+
+func TestProcessRecordsAttempts(t *testing.T) {
+	email := &fakeSender{errs: []error{errors.New("timeout")}}
+	store := &fakeStore{}
+	c := newTestConsumer(store, map[app.ChannelType]sender.Sender{app.Email: email})
+
+	notif := newTestNotification()
+	assert.Equal(t, ack, c.process(notif, false))
+	assert.Equal(t, ack, c.process(notif, false))
+
+	assert.Len(t, store.attempts, 2)
+	assert.Equal(t, app.AttemptFailed, store.attempts[0].Outcome)
+	assert.Equal(t, "timeout", store.attempts[0].Error)
+	assert.Equal(t, 1, store.attempts[0].Attempt)
+	assert.Equal(t, app.AttemptSent, store.attempts[1].Outcome)
+	assert.Equal(t, 2, store.attempts[1].Attempt)
+	assert.Equal(t, notif.ID, store.attempts[1].NotificationID)
+
+	assert.Equal(t, app.Sent, store.saved.Status)
+	assert.Equal(t, app.Email, store.saved.DeliveredChannel)
+}
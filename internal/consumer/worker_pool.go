@@ -0,0 +1,91 @@
+package consumer
+
+import (
+	"context"
+	"delayedNotifier/internal/app"
+	"expvar"
+	"github.com/rabbitmq/amqp091-go"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// workerMetrics публикуется через expvar (/debug/vars) под ключом consumer_workers.
+var workerMetrics = expvar.NewMap("consumer_workers")
+
+type job struct {
+	delivery amqp091.Delivery
+	notif    *app.Notification
+}
+
+// workerPool — пул воркеров одного канала доставки с ограниченной очередью задач.
+type workerPool struct {
+	channel app.ChannelType
+	size    int
+	jobs    chan job
+
+	busy      atomic.Int64
+	processed atomic.Int64
+	busyTime  atomic.Int64 // суммарное время работы воркеров, нс
+	startedAt time.Time
+}
+
+func newWorkerPool(channel app.ChannelType, size, queueSize int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &workerPool{
+		channel:   channel,
+		size:      size,
+		jobs:      make(chan job, queueSize),
+		startedAt: time.Now(),
+	}
+	workerMetrics.Set(string(channel), expvar.Func(p.stats))
+	return p
+}
+
+// start запускает воркеры. Они работают, пока канал задач не будет закрыт;
+// задачи, не начатые до отмены ctx, возвращаются в очередь RabbitMQ.
+func (p *workerPool) start(ctx context.Context, wg *sync.WaitGroup, handle func(job)) {
+	for i := 0; i < p.size; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range p.jobs {
+				if ctx.Err() != nil {
+					if err := j.delivery.Nack(false, true); err != nil {
+						wbzlog.Logger.Error().Err(err).Msg("Failed to requeue message on shutdown")
+					}
+					continue
+				}
+
+				p.busy.Add(1)
+				started := time.Now()
+				handle(j)
+				p.busyTime.Add(int64(time.Since(started)))
+				p.busy.Add(-1)
+				p.processed.Add(1)
+			}
+		}()
+	}
+}
+
+func (p *workerPool) stats() any {
+	elapsed := time.Since(p.startedAt)
+	utilisation := 0.0
+	if elapsed > 0 {
+		utilisation = float64(p.busyTime.Load()) / (float64(elapsed) * float64(p.size))
+	}
+	return map[string]any{
+		"workers":     p.size,
+		"busy":        p.busy.Load(),
+		"queued":      len(p.jobs),
+		"capacity":    cap(p.jobs),
+		"processed":   p.processed.Load(),
+		"utilisation": utilisation,
+	}
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const contactColumns = `id, name, email, telegram_chat_id, phone, channels, locale, timezone, created_at, updated_at`
+
+func scanContact(row rowScanner) (*app.Contact, error) {
+	var c app.Contact
+	var channels []string
+	if err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.Email,
+		&c.TelegramChatID,
+		&c.Phone,
+		pq.Array(&channels),
+		&c.Locale,
+		&c.Timezone,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	c.Channels = make([]app.ChannelType, len(channels))
+	for i, channel := range channels {
+		c.Channels[i] = app.ChannelType(channel)
+	}
+	return &c, nil
+}
+
+func channelsArray(channels []app.ChannelType) any {
+	names := make([]string, len(channels))
+	for i, channel := range channels {
+		names[i] = string(channel)
+	}
+	return pq.Array(names)
+}
+
+func (p *Postgres) SaveContact(c *app.Contact) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO contacts (` + contactColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		c.ID,
+		c.Name,
+		c.Email,
+		c.TelegramChatID,
+		c.Phone,
+		channelsArray(c.Channels),
+		c.Locale,
+		c.Timezone,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert contact query")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetContacts(limit, offset int) ([]*app.Contact, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, limit, offset)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select contacts query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	contacts := make([]*app.Contact, 0, limit)
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan contact row")
+			return nil, err
+		}
+		contacts = append(contacts, c)
+	}
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return contacts, nil
+}
+
+// GetContact возвращает контакт по ID; nil, nil — контакта нет.
+func (p *Postgres) GetContact(id string) (*app.Contact, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts
+		WHERE id = $1
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select contact query")
+		return nil, err
+	}
+	c, err := scanContact(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			wbzlog.Logger.Info().Str("id", id).Msg("Contact not found")
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan contact row")
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateContact сохраняет измененный контакт; false — контакта уже нет.
+func (p *Postgres) UpdateContact(c *app.Contact) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE contacts
+		SET name = $1, email = $2, telegram_chat_id = $3, phone = $4, channels = $5, locale = $6, timezone = $7, updated_at = $8
+		WHERE id = $9
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		c.Name,
+		c.Email,
+		c.TelegramChatID,
+		c.Phone,
+		channelsArray(c.Channels),
+		c.Locale,
+		c.Timezone,
+		c.UpdatedAt,
+		c.ID,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update contact query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// DeleteContact удаляет контакт; его уведомления остаются с последним выбранным адресом.
+// false — удалять было нечего.
+func (p *Postgres) DeleteContact(id string) (bool, error) {
+	ctx := context.Background()
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs},
+		`DELETE FROM contacts WHERE id = $1`, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute delete contact query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UpdateNotificationRoute сохраняет канал, адрес и запасные каналы, выбранные для уведомления
+// контакта при отправке. Строка обновляется, только если уведомление все еще в sending
+// (иначе app.ErrStatusConflict).
+func (p *Postgres) UpdateNotificationRoute(n *app.Notification) error {
+	ctx := context.Background()
+
+	_, _, fallbacks, err := contentJSON(n)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE notifications
+		SET channel = $1, recipient = $2, fallbacks = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		n.Channel,
+		n.Recipient,
+		fallbacks,
+		n.UpdatedAt,
+		n.ID,
+		app.Sending,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update notification route query")
+		return err
+	}
+	return expectAffected(res)
+}
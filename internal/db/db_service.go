@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
+	"encoding/json"
 	"fmt"
+	"github.com/lib/pq"
 	wbdb "github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
@@ -17,6 +19,99 @@ type Postgres struct {
 	cfg *config.RetrysConfig
 }
 
+const notificationColumns = `id, channel, message, send_at, status, created_at, updated_at, recipient, attempts, next_attempt_at, last_error, version, schedule_id, subject, html, attachments, parse_mode, buttons, silent, contact_id, fallbacks, fallback_step, delivered_channel, primary_destination`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row rowScanner) (*app.Notification, error) {
+	var n app.Notification
+	var attachments, buttons, fallbacks, primary []byte
+	if err := row.Scan(
+		&n.ID,
+		&n.Channel,
+		&n.Message,
+		&n.SendAt,
+		&n.Status,
+		&n.CreatedAt,
+		&n.UpdatedAt,
+		&n.Recipient,
+		&n.Attempts,
+		&n.NextAttemptAt,
+		&n.LastError,
+		&n.Version,
+		&n.ScheduleID,
+		&n.Subject,
+		&n.HTML,
+		&attachments,
+		&n.ParseMode,
+		&buttons,
+		&n.Silent,
+		&n.ContactID,
+		&fallbacks,
+		&n.FallbackStep,
+		&n.DeliveredChannel,
+		&primary,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attachments, &n.Attachments); err != nil {
+		return nil, fmt.Errorf("notification %s attachments: %w", n.ID, err)
+	}
+	if err := json.Unmarshal(buttons, &n.Buttons); err != nil {
+		return nil, fmt.Errorf("notification %s buttons: %w", n.ID, err)
+	}
+	if err := json.Unmarshal(fallbacks, &n.Fallbacks); err != nil {
+		return nil, fmt.Errorf("notification %s fallbacks: %w", n.ID, err)
+	}
+	if primary != nil {
+		if err := json.Unmarshal(primary, &n.Primary); err != nil {
+			return nil, fmt.Errorf("notification %s primary destination: %w", n.ID, err)
+		}
+	}
+	return &n, nil
+}
+
+// contentJSON готовит вложения, кнопки и запасные каналы для колонок JSONB. Значения передаются
+// строками: []byte драйвер отправил бы как bytea.
+func contentJSON(n *app.Notification) (attachments, buttons, fallbacks string, err error) {
+	a, err := json.Marshal(nonNil(n.Attachments))
+	if err != nil {
+		return "", "", "", err
+	}
+	b, err := json.Marshal(nonNil(n.Buttons))
+	if err != nil {
+		return "", "", "", err
+	}
+	f, err := json.Marshal(nonNil(n.Fallbacks))
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(a), string(b), string(f), nil
+}
+
+// primaryJSON готовит основной канал для колонки primary_destination: NULL, пока уведомление
+// не перешло на запасной канал.
+func primaryJSON(n *app.Notification) (any, error) {
+	if n.Primary == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(n.Primary)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// nonNil заменяет nil на пустой срез, чтобы в колонку попал [], а не null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
 func NewPostgres(cfg *config.AppConfig) (*Postgres, error) {
 	masterDSN := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
@@ -74,12 +169,16 @@ func (p *Postgres) SaveNotification(notification *app.Notification) error {
 
 	ctx := context.Background()
 
+	attachments, buttons, fallbacks, err := contentJSON(notification)
+	if err != nil {
+		return err
+	}
 	query := `
-		INSERT INTO notifications (id, channel, message, send_at, status, created_at, updated_at, recipient)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO notifications (id, channel, message, send_at, status, created_at, updated_at, recipient, version, subject, html, attachments, parse_mode, buttons, silent, contact_id, fallbacks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
 	`
 
-	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+	_, err = p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
 		notification.ID,
 		notification.Channel,
 		notification.Message,
@@ -88,6 +187,15 @@ func (p *Postgres) SaveNotification(notification *app.Notification) error {
 		notification.CreatedAt,
 		notification.UpdatedAt,
 		notification.Recipient,
+		notification.Version,
+		notification.Subject,
+		notification.HTML,
+		attachments,
+		notification.ParseMode,
+		buttons,
+		notification.Silent,
+		notification.ContactID,
+		fallbacks,
 	)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert notification query")
@@ -97,32 +205,110 @@ func (p *Postgres) SaveNotification(notification *app.Notification) error {
 
 }
 
-func (p *Postgres) GetNotifications(status app.StatusType, batchSize int, lastID string) ([]*app.Notification, error) {
+// SaveNotifications сохраняет пачку уведомлений одной транзакцией через COPY:
+// либо записываются все, либо ни одного. Запрос всегда идет в мастер.
+func (p *Postgres) SaveNotifications(notifications []*app.Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	err := retry.Do(func() error {
+		return p.copyNotifications(ctx, notifications)
+	}, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Int("count", len(notifications)).Msg("Failed to copy notifications batch")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) copyNotifications(ctx context.Context, notifications []*app.Notification) error {
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("notifications",
+		"id", "channel", "message", "send_at", "status", "created_at", "updated_at", "recipient", "version", "subject", "html", "attachments", "parse_mode", "buttons", "silent", "contact_id", "fallbacks"))
+	if err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		attachments, buttons, fallbacks, err := contentJSON(n)
+		if err != nil {
+			_ = stmt.Close()
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx,
+			n.ID,
+			n.Channel,
+			n.Message,
+			n.SendAt,
+			n.Status,
+			n.CreatedAt,
+			n.UpdatedAt,
+			n.Recipient,
+			n.Version,
+			n.Subject,
+			n.HTML,
+			attachments,
+			n.ParseMode,
+			buttons,
+			n.Silent,
+			n.ContactID,
+			fallbacks,
+		); err != nil {
+			_ = stmt.Close()
+			return err
+		}
+	}
+	// пустой Exec сбрасывает буфер COPY на сервер
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		_ = stmt.Close()
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimNotifications захватывает пачку готовых к отправке уведомлений: переводит их в processing
+// и выставляет аренду до now+lease. SKIP LOCKED позволяет нескольким экземплярам сервиса
+// разбирать очередь параллельно, не пересекаясь. Запрос всегда идет в мастер.
+func (p *Postgres) ClaimNotifications(batchSize int, lease time.Duration) ([]*app.Notification, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	query := `
 		UPDATE notifications
-		SET status = 'processing'
+		SET status = $5, lease_until = $3, updated_at = $2
 		WHERE id IN (
 			SELECT id
 			FROM notifications
-			WHERE status = $1
-			AND id > $2
-			AND send_at <= $4
-			ORDER BY id ASC
-			LIMIT $3
+			WHERE status = $4
+			AND COALESCE(next_attempt_at, send_at) <= $2
+			ORDER BY COALESCE(next_attempt_at, send_at) ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
 		)
-		RETURNING id, channel, message, send_at, status, created_at, updated_at, recipient;
+		RETURNING ` + notificationColumns + `;
 	`
 
-	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{
-		Attempts: p.cfg.Attempts,
-		Delay:    p.cfg.Delay,
-		Backoff:  p.cfg.Backoffs,
-	}, query, status, lastID, batchSize, time.Now().Add(3*time.Second))
+	now := time.Now()
+	var rows *sql.Rows
+	err := retry.Do(func() error {
+		r, err := p.db.Master.QueryContext(ctx, query, batchSize, now, now.Add(lease), app.Pending, app.Processing)
+		if err != nil {
+			return err
+		}
+		rows = r
+		return nil
+	}, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs})
 	if err != nil {
-		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select notifications query")
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute claim notifications query")
 		return nil, err
 	}
 	defer func() {
@@ -133,21 +319,12 @@ func (p *Postgres) GetNotifications(status app.StatusType, batchSize int, lastID
 
 	var notifications []*app.Notification
 	for rows.Next() {
-		var n app.Notification
-		if err := rows.Scan(
-			&n.ID,
-			&n.Channel,
-			&n.Message,
-			&n.SendAt,
-			&n.Status,
-			&n.CreatedAt,
-			&n.UpdatedAt,
-			&n.Recipient,
-		); err != nil {
+		n, err := scanNotification(rows)
+		if err != nil {
 			wbzlog.Logger.Error().Err(err).Msg("Failed to scan notification row")
 			return nil, err
 		}
-		notifications = append(notifications, &n)
+		notifications = append(notifications, n)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -158,10 +335,94 @@ func (p *Postgres) GetNotifications(status app.StatusType, batchSize int, lastID
 	return notifications, nil
 }
 
+// MarkNotificationQueued снимает аренду после подтверждения публикации в RabbitMQ.
+// Если консьюмер уже успел обработать уведомление, возвращается app.ErrStatusConflict.
+func (p *Postgres) MarkNotificationQueued(id string) error {
+	return p.finishClaim(id, app.Queued)
+}
+
+// ReleaseNotification возвращает захваченное уведомление в pending, например если публикация не удалась.
+func (p *Postgres) ReleaseNotification(id string) error {
+	return p.finishClaim(id, app.Pending)
+}
+
+func (p *Postgres) finishClaim(id string, status app.StatusType) error {
+	if err := app.ValidateTransition(app.Processing, status); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	query := `
+		UPDATE notifications
+		SET status = $1, lease_until = NULL, updated_at = $2
+		WHERE id = $3 AND status = $4
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		status,
+		time.Now(),
+		id,
+		app.Processing,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Str("status", string(status)).Msg("Failed to execute finish claim query")
+		return err
+	}
+	return expectAffected(res)
+}
+
+// ReapExpiredLeases возвращает в pending уведомления, чья аренда истекла
+// (например, экземпляр упал между захватом и публикацией), и их идентификаторы.
+func (p *Postgres) ReapExpiredLeases() ([]string, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE notifications
+		SET status = $2, lease_until = NULL, updated_at = $1
+		WHERE status = $3 AND lease_until < $1
+		RETURNING id
+	`
+
+	var rows *sql.Rows
+	err := retry.Do(func() error {
+		r, err := p.db.Master.QueryContext(ctx, query, time.Now(), app.Pending, app.Processing)
+		if err != nil {
+			return err
+		}
+		rows = r
+		return nil
+	}, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute reap expired leases query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan reaped notification id")
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return ids, nil
+}
+
 func (p *Postgres) GetNotification(id string) (*app.Notification, error) {
 	ctx := context.Background()
 	query := `
-		SELECT id, channel, message, send_at, status, created_at, updated_at, recipient
+		SELECT ` + notificationColumns + `
 		FROM notifications
 		WHERE id = $1
 	`
@@ -172,17 +433,7 @@ func (p *Postgres) GetNotification(id string) (*app.Notification, error) {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select notifications query")
 		return nil, err
 	}
-	var notification app.Notification
-	err = row.Scan(
-		&notification.ID,
-		&notification.Channel,
-		&notification.Message,
-		&notification.SendAt,
-		&notification.Status,
-		&notification.CreatedAt,
-		&notification.UpdatedAt,
-		&notification.Recipient,
-	)
+	notification, err := scanNotification(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			wbzlog.Logger.Info().Str("id", id).Msg("Notification not found")
@@ -195,27 +446,127 @@ func (p *Postgres) GetNotification(id string) (*app.Notification, error) {
 		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
 		return nil, err
 	}
-	return &notification, nil
+	return notification, nil
 }
 
-func (p *Postgres) UpdateNotificationStatus(id string, status app.StatusType) error {
+// TransitionNotification переводит уведомление в статус to, только если его текущий статус
+// входит в from. Запрещенный таблицей переход возвращает app.ErrInvalidTransition,
+// несовпадение статуса в БД — app.ErrStatusConflict.
+func (p *Postgres) TransitionNotification(id string, to app.StatusType, from ...app.StatusType) error {
+	expected := make([]string, 0, len(from))
+	for _, status := range from {
+		if err := app.ValidateTransition(status, to); err != nil {
+			return err
+		}
+		expected = append(expected, string(status))
+	}
+
 	ctx := context.Background()
 
 	query := `
 		UPDATE notifications
 		SET status = $1, updated_at = $2
-		WHERE id = $3
+		WHERE id = $3 AND status = ANY($4)
 	`
 
-	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
-		status,
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		to,
 		time.Now(),
 		id,
+		pq.Array(expected),
 	)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update notification status query")
 		return err
 	}
+	return expectAffected(res)
+}
+
+// UpdateDeliveryState сохраняет результат попытки доставки: статус, счетчик попыток,
+// время следующей попытки, последнюю ошибку, а также текущий канал и адрес с шагом цепочки
+// запасных каналов, основной канал и канал, через который уведомление доставлено.
+// Строка обновляется, только если ее статус равен expected.
+func (p *Postgres) UpdateDeliveryState(notification *app.Notification, expected app.StatusType) error {
+	if err := app.ValidateTransition(expected, notification.Status); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	primary, err := primaryJSON(notification)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE notifications
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_error = $4, updated_at = $5,
+			channel = $8, recipient = $9, fallback_step = $10, delivered_channel = $11, primary_destination = $12
+		WHERE id = $6 AND status = $7
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		notification.Status,
+		notification.Attempts,
+		notification.NextAttemptAt,
+		notification.LastError,
+		time.Now(),
+		notification.ID,
+		expected,
+		notification.Channel,
+		notification.Recipient,
+		notification.FallbackStep,
+		notification.DeliveredChannel,
+		primary,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update delivery state query")
+		return err
+	}
+	return expectAffected(res)
+}
+
+// expectAffected превращает "ни одна строка не обновлена" в app.ErrStatusConflict.
+func expectAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return app.ErrStatusConflict
+	}
+	return nil
+}
+
+// UpdateNotification сохраняет изменения ожидающего уведомления с оптимистической блокировкой:
+// строка обновляется, только если ее версия равна expectedVersion, а статус — pending.
+// Иначе возвращается app.ErrVersionConflict. При успехе версия уведомления увеличивается.
+func (p *Postgres) UpdateNotification(notification *app.Notification, expectedVersion int) error {
+	ctx := context.Background()
+
+	query := `
+		UPDATE notifications
+		SET message = $1, recipient = $2, send_at = $3, next_attempt_at = $4, updated_at = $5, version = version + 1
+		WHERE id = $6 AND version = $7 AND status = $8
+		RETURNING version
+	`
+
+	row := p.db.Master.QueryRowContext(ctx, query,
+		notification.Message,
+		notification.Recipient,
+		notification.SendAt,
+		notification.NextAttemptAt,
+		notification.UpdatedAt,
+		notification.ID,
+		expectedVersion,
+		app.Pending,
+	)
+	if err := row.Scan(&notification.Version); err != nil {
+		if err == sql.ErrNoRows {
+			return app.ErrVersionConflict
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update notification query")
+		return err
+	}
 	return nil
 }
 
@@ -244,7 +595,7 @@ func (p *Postgres) UploadCache(limit int) ([]*app.Notification, error) {
 	ctx := context.Background()
 
 	query := `
-		SELECT id, channel, message, send_at, status, created_at, updated_at, recipient
+		SELECT ` + notificationColumns + `
 		FROM notifications
 		ORDER BY created_at DESC
 		LIMIT $1
@@ -265,22 +616,12 @@ func (p *Postgres) UploadCache(limit int) ([]*app.Notification, error) {
 
 	var notifications []*app.Notification
 	for rows.Next() {
-		var n app.Notification
-		err := rows.Scan(
-			&n.ID,
-			&n.Channel,
-			&n.Message,
-			&n.SendAt,
-			&n.Status,
-			&n.CreatedAt,
-			&n.UpdatedAt,
-			&n.Recipient,
-		)
+		n, err := scanNotification(rows)
 		if err != nil {
 			wbzlog.Logger.Error().Err(err).Msg("Failed to scan notification row")
 			return nil, err
 		}
-		notifications = append(notifications, &n)
+		notifications = append(notifications, n)
 	}
 
 	if err = rows.Err(); err != nil {
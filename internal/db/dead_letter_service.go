@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const deadLetterColumns = `id, notification_id, reason, body, death_count, created_at, replayed_at`
+
+func scanDeadLetter(row rowScanner) (*app.DeadLetter, error) {
+	var dl app.DeadLetter
+	if err := row.Scan(
+		&dl.ID,
+		&dl.NotificationID,
+		&dl.Reason,
+		&dl.Body,
+		&dl.DeathCount,
+		&dl.CreatedAt,
+		&dl.ReplayedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+func (p *Postgres) SaveDeadLetter(dl *app.DeadLetter) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO dead_letters (` + deadLetterColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		dl.ID,
+		dl.NotificationID,
+		dl.Reason,
+		dl.Body,
+		dl.DeathCount,
+		dl.CreatedAt,
+		dl.ReplayedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert dead letter query")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetDeadLetters(limit, offset int) ([]*app.DeadLetter, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, limit, offset)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select dead letters query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	deadLetters := make([]*app.DeadLetter, 0, limit)
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan dead letter row")
+			return nil, err
+		}
+		deadLetters = append(deadLetters, dl)
+	}
+
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return deadLetters, nil
+}
+
+func (p *Postgres) GetDeadLetter(id string) (*app.DeadLetter, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letters
+		WHERE id = $1
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select dead letter query")
+		return nil, err
+	}
+	dl, err := scanDeadLetter(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			wbzlog.Logger.Info().Str("id", id).Msg("Dead letter not found")
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan dead letter row")
+		return nil, err
+	}
+	return dl, nil
+}
+
+// ReplayDeadLetter в одной транзакции возвращает уведомление из failed в pending
+// (восстанавливая строку, если ее успели удалить) и помечает dead letter как переотправленный.
+// Канал, адрес и шаг цепочки запасных каналов берутся из notification (см. app.Notification.PrepareForReplay).
+// Если уведомление не в статусе failed, возвращается app.ErrStatusConflict.
+func (p *Postgres) ReplayDeadLetter(dl *app.DeadLetter, notification *app.Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	attachments, buttons, fallbacks, err := contentJSON(notification)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to begin replay transaction")
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	upsert := `
+		INSERT INTO notifications (id, channel, message, send_at, status, created_at, updated_at, recipient, attempts, next_attempt_at, last_error, subject, html, attachments, parse_mode, buttons, silent, contact_id, fallbacks, fallback_step)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $13, $14, $15, $16, $17, $18,
+			(SELECT id FROM contacts WHERE id = $19), -- контакт могли удалить, пока уведомление лежало в DLQ
+			$20, $21)
+		ON CONFLICT (id) DO UPDATE
+		SET status = EXCLUDED.status,
+			attempts = EXCLUDED.attempts,
+			next_attempt_at = EXCLUDED.next_attempt_at,
+			last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at,
+			channel = EXCLUDED.channel,
+			recipient = EXCLUDED.recipient,
+			fallback_step = EXCLUDED.fallback_step,
+			primary_destination = NULL,
+			delivered_channel = ''
+		WHERE notifications.status = $12
+	`
+	res, err := tx.ExecContext(ctx, upsert,
+		notification.ID,
+		notification.Channel,
+		notification.Message,
+		notification.SendAt,
+		notification.Status,
+		notification.CreatedAt,
+		notification.UpdatedAt,
+		notification.Recipient,
+		notification.Attempts,
+		notification.NextAttemptAt,
+		notification.LastError,
+		app.Failed,
+		notification.Subject,
+		notification.HTML,
+		attachments,
+		notification.ParseMode,
+		buttons,
+		notification.Silent,
+		notification.ContactID,
+		fallbacks,
+		notification.FallbackStep,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to upsert replayed notification")
+		return err
+	}
+	// переотправить можно только окончательно упавшее (или удаленное) уведомление
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+
+	replayedAt := time.Now()
+	if _, err := tx.ExecContext(ctx, `UPDATE dead_letters SET replayed_at = $1 WHERE id = $2`, replayedAt, dl.ID); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to mark dead letter as replayed")
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to commit replay transaction")
+		return err
+	}
+	dl.ReplayedAt = &replayedAt
+	return nil
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const deliveryAttemptColumns = `id, notification_id, channel, recipient, attempt, fallback_step, outcome, error, created_at`
+
+func scanDeliveryAttempt(row rowScanner) (*app.DeliveryAttempt, error) {
+	var a app.DeliveryAttempt
+	if err := row.Scan(
+		&a.ID,
+		&a.NotificationID,
+		&a.Channel,
+		&a.Recipient,
+		&a.Attempt,
+		&a.FallbackStep,
+		&a.Outcome,
+		&a.Error,
+		&a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// SaveDeliveryAttempt записывает попытку отправки в историю уведомления.
+func (p *Postgres) SaveDeliveryAttempt(a *app.DeliveryAttempt) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO delivery_attempts (` + deliveryAttemptColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		a.ID,
+		a.NotificationID,
+		a.Channel,
+		a.Recipient,
+		a.Attempt,
+		a.FallbackStep,
+		a.Outcome,
+		a.Error,
+		a.CreatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert delivery attempt query")
+		return err
+	}
+	return nil
+}
+
+// GetDeliveryAttempts возвращает историю попыток отправки уведомления в хронологическом порядке.
+func (p *Postgres) GetDeliveryAttempts(notificationID string) ([]*app.DeliveryAttempt, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + deliveryAttemptColumns + `
+		FROM delivery_attempts
+		WHERE notification_id = $1
+		ORDER BY created_at, fallback_step, attempt
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, notificationID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select delivery attempts query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	result := []*app.DeliveryAttempt{}
+	for rows.Next() {
+		a, err := scanDeliveryAttempt(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan delivery attempt row")
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return result, nil
+}
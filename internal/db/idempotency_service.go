@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+// SaveNotificationIdempotent сохраняет уведомление вместе с ключом идемпотентности одной транзакцией.
+// Если живой ключ уже есть, новое уведомление не создается: возвращается ранее созданное и created=false.
+// Ключ с другим отпечатком запроса дает app.ErrIdempotencyKeyReused. Просроченный ключ
+// перезаписывается, как будто его не было. Параллельные запросы с одним ключом сериализуются
+// уникальным индексом: второй ждет коммита первого и получает его уведомление.
+func (p *Postgres) SaveNotificationIdempotent(notification *app.Notification, key *app.IdempotencyKey) (*app.Notification, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to begin idempotent insert transaction")
+		return nil, false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// уведомление вставляется первым, чтобы внешний ключ idempotency_keys был удовлетворен;
+	// если ключ окажется занят, транзакция откатится вместе с ним
+	attachments, buttons, fallbacks, err := contentJSON(notification)
+	if err != nil {
+		return nil, false, err
+	}
+	insert := `
+		INSERT INTO notifications (id, channel, message, send_at, status, created_at, updated_at, recipient, version, subject, html, attachments, parse_mode, buttons, silent, contact_id, fallbacks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+	`
+	if _, err := tx.ExecContext(ctx, insert,
+		notification.ID,
+		notification.Channel,
+		notification.Message,
+		notification.SendAt,
+		notification.Status,
+		notification.CreatedAt,
+		notification.UpdatedAt,
+		notification.Recipient,
+		notification.Version,
+		notification.Subject,
+		notification.HTML,
+		attachments,
+		notification.ParseMode,
+		buttons,
+		notification.Silent,
+		notification.ContactID,
+		fallbacks,
+	); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert notification query")
+		return nil, false, err
+	}
+
+	claim := `
+		INSERT INTO idempotency_keys (key, request_hash, notification_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			notification_id = EXCLUDED.notification_id,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`
+	res, err := tx.ExecContext(ctx, claim, key.Key, key.RequestHash, key.NotificationID, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute claim idempotency key query")
+		return nil, false, err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return nil, false, err
+	} else if affected == 1 {
+		if err := tx.Commit(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to commit idempotent insert transaction")
+			return nil, false, err
+		}
+		return notification, true, nil
+	}
+
+	// ключ занят живой записью — возвращаем уведомление, созданное по нему
+	var existing app.IdempotencyKey
+	lookup := `
+		SELECT request_hash, notification_id
+		FROM idempotency_keys
+		WHERE key = $1
+	`
+	if err := tx.QueryRowContext(ctx, lookup, key.Key).Scan(&existing.RequestHash, &existing.NotificationID); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select idempotency key query")
+		return nil, false, err
+	}
+	if existing.RequestHash != key.RequestHash {
+		return nil, false, app.ErrIdempotencyKeyReused
+	}
+
+	original, err := scanNotification(tx.QueryRowContext(ctx, `
+		SELECT `+notificationColumns+`
+		FROM notifications
+		WHERE id = $1
+	`, existing.NotificationID))
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan notification row")
+		return nil, false, err
+	}
+	return original, false, nil
+}
+
+// GetIdempotentNotification возвращает уведомление, созданное по живому ключу идемпотентности;
+// nil, nil — ключа нет, он просрочен или уведомление уже удалено. Ключ с другим отпечатком
+// запроса дает app.ErrIdempotencyKeyReused.
+func (p *Postgres) GetIdempotentNotification(key, requestHash string) (*app.Notification, error) {
+	ctx := context.Background()
+	query := `
+		SELECT request_hash, notification_id
+		FROM idempotency_keys
+		WHERE key = $1 AND expires_at > $2
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, key, time.Now())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select idempotency key query")
+		return nil, err
+	}
+	var existing app.IdempotencyKey
+	if err := row.Scan(&existing.RequestHash, &existing.NotificationID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan idempotency key row")
+		return nil, err
+	}
+	if existing.RequestHash != requestHash {
+		return nil, app.ErrIdempotencyKeyReused
+	}
+	return p.GetNotification(existing.NotificationID.String())
+}
+
+// PurgeExpiredIdempotencyKeys удаляет ключи, срок хранения которых истек.
+func (p *Postgres) PurgeExpiredIdempotencyKeys() (int64, error) {
+	ctx := context.Background()
+
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE expires_at <= $1
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, time.Now())
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute purge idempotency keys query")
+		return 0, err
+	}
+	return res.RowsAffected()
+}
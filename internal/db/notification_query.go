@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"delayedNotifier/internal/app"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"strings"
+)
+
+// sortColumns — белый список колонок для ORDER BY, имя колонки никогда не берется из запроса напрямую.
+var sortColumns = map[app.SortField]string{
+	app.SortByCreatedAt: "created_at",
+	app.SortBySendAt:    "send_at",
+	app.SortByUpdatedAt: "updated_at",
+}
+
+// likeEscaper экранирует спецсимволы LIKE, чтобы поиск шел по буквальной подстроке.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// queryBuilder собирает WHERE из условий и нумерует плейсхолдеры по мере добавления аргументов.
+type queryBuilder struct {
+	where []string
+	args  []any
+}
+
+func (b *queryBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) cond(format string, args ...any) {
+	placeholders := make([]any, 0, len(args))
+	for _, a := range args {
+		placeholders = append(placeholders, b.arg(a))
+	}
+	b.where = append(b.where, fmt.Sprintf(format, placeholders...))
+}
+
+// ListNotifications возвращает до q.Limit+1 уведомлений, подходящих под фильтр, в порядке сортировки.
+// Лишний элемент нужен вызывающему, чтобы понять, есть ли следующая страница (см. app.NewNotificationPage).
+// Пагинация keyset-ом по (поле сортировки, id), поэтому страницы не смещаются при вставке новых строк.
+func (p *Postgres) ListNotifications(q app.NotificationQuery) ([]*app.Notification, error) {
+	column, ok := sortColumns[q.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", q.Sort)
+	}
+	direction, cmp := "DESC", "<"
+	if q.Order == app.Asc {
+		direction, cmp = "ASC", ">"
+	}
+
+	var b queryBuilder
+	f := q.Filter
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, 0, len(f.Statuses))
+		for _, s := range f.Statuses {
+			statuses = append(statuses, string(s))
+		}
+		b.cond("status = ANY(%s)", pq.Array(statuses))
+	}
+	if f.Channel != "" {
+		b.cond("channel = %s", f.Channel)
+	}
+	if f.Recipient != "" {
+		b.cond("recipient = %s", f.Recipient)
+	}
+	if f.SendFrom != nil {
+		b.cond("send_at >= %s", *f.SendFrom)
+	}
+	if f.SendTo != nil {
+		b.cond("send_at < %s", *f.SendTo)
+	}
+	if f.CreatedFrom != nil {
+		b.cond("created_at >= %s", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		b.cond("created_at < %s", *f.CreatedTo)
+	}
+	if f.Query != "" {
+		b.cond("message ILIKE %s", "%"+likeEscaper.Replace(f.Query)+"%")
+	}
+	if q.After != nil {
+		b.cond("("+column+", id) "+cmp+" (%s, %s)", q.After.Value, q.After.ID)
+	}
+
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications`
+	if len(b.where) > 0 {
+		query += `
+		WHERE ` + strings.Join(b.where, " AND ")
+	}
+	query += `
+		ORDER BY ` + column + ` ` + direction + `, id ` + direction + `
+		LIMIT ` + b.arg(q.Limit+1)
+
+	ctx := context.Background()
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, b.args...)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute list notifications query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	notifications := make([]*app.Notification, 0, q.Limit+1)
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan notification row")
+			return nil, err
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const pushSubscriptionColumns = `id, recipient, endpoint, p256dh, auth, user_agent, created_at, updated_at`
+
+func scanPushSubscription(row rowScanner) (*app.PushSubscription, error) {
+	var s app.PushSubscription
+	if err := row.Scan(
+		&s.ID,
+		&s.Recipient,
+		&s.Endpoint,
+		&s.P256dh,
+		&s.Auth,
+		&s.UserAgent,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SavePushSubscription сохраняет подписку. Браузер повторно регистрирует тот же endpoint
+// (например, после обновления ключей), поэтому существующая запись обновляется, а ID и created_at
+// в s заменяются сохраненными.
+func (p *Postgres) SavePushSubscription(s *app.PushSubscription) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO push_subscriptions (` + pushSubscriptionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (endpoint) DO UPDATE
+		SET recipient = EXCLUDED.recipient,
+			p256dh = EXCLUDED.p256dh,
+			auth = EXCLUDED.auth,
+			user_agent = EXCLUDED.user_agent,
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, created_at
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		s.ID,
+		s.Recipient,
+		s.Endpoint,
+		s.P256dh,
+		s.Auth,
+		s.UserAgent,
+		s.CreatedAt,
+		s.UpdatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute upsert push subscription query")
+		return err
+	}
+	if err := row.Scan(&s.ID, &s.CreatedAt); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan push subscription row")
+		return err
+	}
+	return nil
+}
+
+// GetPushSubscriptions возвращает все подписки получателя.
+func (p *Postgres) GetPushSubscriptions(recipient string) ([]*app.PushSubscription, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + pushSubscriptionColumns + `
+		FROM push_subscriptions
+		WHERE recipient = $1
+		ORDER BY created_at
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, recipient)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select push subscriptions query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	result := []*app.PushSubscription{}
+	for rows.Next() {
+		s, err := scanPushSubscription(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan push subscription row")
+			return nil, err
+		}
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeletePushSubscription удаляет подписку по ID; false — удалять было нечего.
+func (p *Postgres) DeletePushSubscription(id string) (bool, error) {
+	return p.deletePushSubscriptions(`DELETE FROM push_subscriptions WHERE id = $1`, id)
+}
+
+// DeletePushSubscriptionByEndpoint удаляет подписку, которую push-сервис объявил недействительной.
+func (p *Postgres) DeletePushSubscriptionByEndpoint(endpoint string) (bool, error) {
+	return p.deletePushSubscriptions(`DELETE FROM push_subscriptions WHERE endpoint = $1`, endpoint)
+}
+
+func (p *Postgres) deletePushSubscriptions(query string, arg string) (bool, error) {
+	ctx := context.Background()
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, arg)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute delete push subscription query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
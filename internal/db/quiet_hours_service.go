@@ -0,0 +1,143 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+const quietHoursColumns = `recipient, channel, timezone, start_time, end_time, created_at, updated_at`
+
+func scanQuietHours(row rowScanner) (*app.QuietHours, error) {
+	var q app.QuietHours
+	if err := row.Scan(
+		&q.Recipient,
+		&q.Channel,
+		&q.Timezone,
+		&q.Start,
+		&q.End,
+		&q.CreatedAt,
+		&q.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// SaveQuietHours создает или заменяет тихие часы получателя для канала.
+func (p *Postgres) SaveQuietHours(q *app.QuietHours) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO quiet_hours (` + quietHoursColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (recipient, channel) DO UPDATE
+		SET timezone = EXCLUDED.timezone,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		q.Recipient,
+		q.Channel,
+		q.Timezone,
+		q.Start,
+		q.End,
+		q.CreatedAt,
+		q.UpdatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute upsert quiet hours query")
+		return err
+	}
+	return nil
+}
+
+// GetQuietHours возвращает тихие часы, действующие для получателя в канале:
+// настройка для конкретного канала важнее общей (с пустым channel). nil, nil — тихих часов нет.
+func (p *Postgres) GetQuietHours(recipient string, channel app.ChannelType) (*app.QuietHours, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + quietHoursColumns + `
+		FROM quiet_hours
+		WHERE recipient = $1 AND channel IN ($2, '')
+		ORDER BY channel DESC
+		LIMIT 1
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, recipient, channel)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select quiet hours query")
+		return nil, err
+	}
+	q, err := scanQuietHours(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan quiet hours row")
+		return nil, err
+	}
+	return q, nil
+}
+
+// ListQuietHours возвращает все настройки тихих часов получателя.
+func (p *Postgres) ListQuietHours(recipient string) ([]*app.QuietHours, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + quietHoursColumns + `
+		FROM quiet_hours
+		WHERE recipient = $1
+		ORDER BY channel
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, recipient)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select quiet hours query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	result := []*app.QuietHours{}
+	for rows.Next() {
+		q, err := scanQuietHours(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan quiet hours row")
+			return nil, err
+		}
+		result = append(result, q)
+	}
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteQuietHours удаляет тихие часы получателя для канала; false — удалять было нечего.
+func (p *Postgres) DeleteQuietHours(recipient string, channel app.ChannelType) (bool, error) {
+	ctx := context.Background()
+
+	query := `
+		DELETE FROM quiet_hours
+		WHERE recipient = $1 AND channel = $2
+	`
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, recipient, channel)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute delete quiet hours query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,324 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"time"
+)
+
+const scheduleColumns = `id, channel, recipient, message, kind, expression, timezone, start_at, end_at, max_occurrences, occurrences, next_run_at, status, created_at, updated_at`
+
+func scanSchedule(row rowScanner) (*app.Schedule, error) {
+	var s app.Schedule
+	if err := row.Scan(
+		&s.ID,
+		&s.Channel,
+		&s.Recipient,
+		&s.Message,
+		&s.Kind,
+		&s.Expression,
+		&s.Timezone,
+		&s.StartAt,
+		&s.EndAt,
+		&s.MaxOccurrences,
+		&s.Occurrences,
+		&s.NextRunAt,
+		&s.Status,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (p *Postgres) SaveSchedule(s *app.Schedule) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO schedules (` + scheduleColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	_, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		s.ID,
+		s.Channel,
+		s.Recipient,
+		s.Message,
+		s.Kind,
+		s.Expression,
+		s.Timezone,
+		s.StartAt,
+		s.EndAt,
+		s.MaxOccurrences,
+		s.Occurrences,
+		s.NextRunAt,
+		s.Status,
+		s.CreatedAt,
+		s.UpdatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute insert schedule query")
+		return err
+	}
+	return nil
+}
+
+func (p *Postgres) GetSchedules(limit, offset int) ([]*app.Schedule, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := p.db.QueryWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, limit, offset)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select schedules query")
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	schedules := make([]*app.Schedule, 0, limit)
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Msg("Failed to scan schedule row")
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Row iteration error")
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (p *Postgres) GetSchedule(id string) (*app.Schedule, error) {
+	ctx := context.Background()
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE id = $1
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, id)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select schedule query")
+		return nil, err
+	}
+	s, err := scanSchedule(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			wbzlog.Logger.Info().Str("id", id).Msg("Schedule not found")
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan schedule row")
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpdateScheduleStatus сохраняет новый статус расписания и время следующего срабатывания,
+// если текущий статус в БД равен expected (иначе app.ErrScheduleConflict).
+// При паузе уже порожденные, но еще не взятые в работу уведомления отменяются в той же транзакции,
+// а их срабатывания возвращаются в счетчик occurrences.
+func (p *Postgres) UpdateScheduleStatus(s *app.Schedule, expected app.ScheduleStatus) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to begin schedule status transaction")
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if s.Status == app.SchedulePaused {
+		canceled, err := cancelScheduled(ctx, tx, s.ID.String())
+		if err != nil {
+			return err
+		}
+		s.ReturnOccurrences(int(canceled))
+	}
+
+	update := `
+		UPDATE schedules
+		SET status = $1, next_run_at = $2, occurrences = $3, updated_at = $4
+		WHERE id = $5 AND status = $6
+	`
+	res, err := tx.ExecContext(ctx, update, s.Status, s.NextRunAt, s.Occurrences, s.UpdatedAt, s.ID, expected)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute update schedule status query")
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return app.ErrScheduleConflict
+	}
+	return tx.Commit()
+}
+
+// DeleteSchedule удаляет расписание и отменяет порожденные им уведомления, которые еще не взяты в работу.
+// Отправленные уведомления остаются в истории с schedule_id = NULL.
+func (p *Postgres) DeleteSchedule(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to begin delete schedule transaction")
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := cancelScheduled(ctx, tx, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1`, id); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute delete schedule query")
+		return err
+	}
+	return tx.Commit()
+}
+
+// cancelScheduled отменяет еще не взятые в работу уведомления расписания и возвращает их число.
+// Отмененные строки не занимают уникальный индекс (schedule_id, send_at), поэтому после
+// возобновления те же срабатывания создаются заново.
+func cancelScheduled(ctx context.Context, tx *sql.Tx, scheduleID string) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET status = $1, updated_at = $2
+		WHERE schedule_id = $3 AND status = $4
+	`
+	res, err := tx.ExecContext(ctx, query, app.Canceled, time.Now(), scheduleID, app.Pending)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to cancel scheduled notifications")
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// MaterializeSchedules порождает уведомления для активных расписаний, чье следующее срабатывание
+// не позже horizon: до batchSize расписаний и до perSchedule уведомлений на каждое за вызов.
+// Расписания захватываются через SKIP LOCKED, а уникальный индекс (schedule_id, send_at)
+// не дает создать одно срабатывание дважды. Возвращает число созданных уведомлений.
+func (p *Postgres) MaterializeSchedules(horizon time.Time, batchSize, perSchedule int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var created int
+	err := retry.Do(func() error {
+		n, err := p.materializeSchedules(ctx, horizon, batchSize, perSchedule)
+		created = n
+		return err
+	}, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs})
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to materialize schedules")
+		return 0, err
+	}
+	return created, nil
+}
+
+func (p *Postgres) materializeSchedules(ctx context.Context, horizon time.Time, batchSize, perSchedule int) (int, error) {
+	tx, err := p.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM schedules
+		WHERE status = $1 AND next_run_at <= $2
+		ORDER BY next_run_at ASC
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`, app.ScheduleActive, horizon, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var schedules []*app.Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		schedules = append(schedules, s)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	insert := `
+		INSERT INTO notifications (id, channel, message, send_at, status, created_at, updated_at, recipient, version, schedule_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (schedule_id, send_at) WHERE schedule_id IS NOT NULL AND status <> 'canceled' DO NOTHING
+	`
+	update := `
+		UPDATE schedules
+		SET occurrences = $1, next_run_at = $2, status = $3, updated_at = $4
+		WHERE id = $5
+	`
+
+	created := 0
+	for _, s := range schedules {
+		notifications, err := s.Materialize(horizon, perSchedule)
+		if err != nil {
+			// битое расписание не должно останавливать остальные
+			wbzlog.Logger.Error().Err(err).Str("schedule_id", s.ID.String()).Msg("Failed to materialize schedule")
+			continue
+		}
+		for _, n := range notifications {
+			res, err := tx.ExecContext(ctx, insert,
+				n.ID,
+				n.Channel,
+				n.Message,
+				n.SendAt,
+				n.Status,
+				n.CreatedAt,
+				n.UpdatedAt,
+				n.Recipient,
+				n.Version,
+				n.ScheduleID,
+			)
+			if err != nil {
+				return 0, err
+			}
+			if affected, _ := res.RowsAffected(); affected == 1 {
+				created++
+			}
+		}
+		if _, err := tx.ExecContext(ctx, update, s.Occurrences, s.NextRunAt, s.Status, s.UpdatedAt, s.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"delayedNotifier/internal/app"
+	"github.com/wb-go/wbf/retry"
+	wbzlog "github.com/wb-go/wbf/zlog"
+)
+
+// SaveTelegramSubscriber сохраняет чат, запустивший бота. Повторный /start обновляет username
+// и имя (их можно сменить в Telegram), а subscribed_at в s заменяется сохраненным.
+func (p *Postgres) SaveTelegramSubscriber(s *app.TelegramSubscriber) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO telegram_subscribers (chat_id, username, first_name, subscribed_at, updated_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		ON CONFLICT (chat_id) DO UPDATE
+		SET username = EXCLUDED.username,
+			first_name = EXCLUDED.first_name,
+			updated_at = EXCLUDED.updated_at
+		RETURNING subscribed_at
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query,
+		s.ChatID,
+		s.Username,
+		s.FirstName,
+		s.SubscribedAt,
+		s.UpdatedAt,
+	)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute upsert telegram subscriber query")
+		return err
+	}
+	if err := row.Scan(&s.SubscribedAt); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan telegram subscriber row")
+		return err
+	}
+	return nil
+}
+
+// GetTelegramSubscriberByUsername ищет подписчика по username без учета регистра;
+// nil, nil — такого подписчика нет.
+func (p *Postgres) GetTelegramSubscriberByUsername(username string) (*app.TelegramSubscriber, error) {
+	ctx := context.Background()
+	query := `
+		SELECT chat_id, COALESCE(username, ''), first_name, subscribed_at, updated_at
+		FROM telegram_subscribers
+		WHERE lower(username) = lower($1)
+		ORDER BY updated_at DESC
+		LIMIT 1
+	`
+
+	row, err := p.db.QueryRowWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs}, query, username)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute select telegram subscriber query")
+		return nil, err
+	}
+
+	var s app.TelegramSubscriber
+	if err := row.Scan(&s.ChatID, &s.Username, &s.FirstName, &s.SubscribedAt, &s.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		wbzlog.Logger.Error().Err(err).Msg("Failed to scan telegram subscriber row")
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteTelegramSubscriber удаляет подписчика (/stop или бот заблокирован); false — удалять было нечего.
+func (p *Postgres) DeleteTelegramSubscriber(chatID int64) (bool, error) {
+	ctx := context.Background()
+
+	res, err := p.db.ExecWithRetry(ctx, retry.Strategy{Attempts: p.cfg.Attempts, Delay: p.cfg.Delay, Backoff: p.cfg.Backoffs},
+		`DELETE FROM telegram_subscribers WHERE chat_id = $1`, chatID)
+	if err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to execute delete telegram subscriber query")
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
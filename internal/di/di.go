@@ -7,22 +7,26 @@ import (
 	"delayedNotifier/internal/consumer"
 	"delayedNotifier/internal/db"
 	"delayedNotifier/internal/redis"
+	"delayedNotifier/internal/sender"
 	"delayedNotifier/internal/web"
+	"expvar"
 	"fmt"
 	wbgin "github.com/wb-go/wbf/ginext"
 	"go.uber.org/fx"
 	"log"
 	"net/http"
+	"sync"
 )
 
-func StartHTTPServer(lc fx.Lifecycle, notifyHandler *web.NotifyHandler, config *config.AppConfig) {
+func StartHTTPServer(lc fx.Lifecycle, notifyHandler *web.NotifyHandler, deadLetterHandler *web.DeadLetterHandler, scheduleHandler *web.ScheduleHandler, quietHoursHandler *web.QuietHoursHandler, pushHandler *web.PushHandler, telegramHandler *web.TelegramHandler, contactHandler *web.ContactHandler, config *config.AppConfig) {
 	router := wbgin.New(config.GinConfig.Mode)
 
 	router.Use(wbgin.Logger(), wbgin.Recovery())
 	router.Use(func(c *wbgin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH, PUT")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Idempotent-Replayed")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -30,7 +34,7 @@ func StartHTTPServer(lc fx.Lifecycle, notifyHandler *web.NotifyHandler, config *
 		c.Next()
 	})
 
-	web.RegisterRoutes(router, notifyHandler)
+	web.RegisterRoutes(router, notifyHandler, deadLetterHandler, scheduleHandler, quietHoursHandler, pushHandler, telegramHandler, contactHandler)
 
 	addres := fmt.Sprintf("%s:%d", config.ServerConfig.Host, config.ServerConfig.Port)
 	server := &http.Server{
@@ -55,6 +59,40 @@ func StartHTTPServer(lc fx.Lifecycle, notifyHandler *web.NotifyHandler, config *
 	})
 }
 
+// StartDebugServer поднимает служебный листенер с метриками expvar (/debug/vars: consumer_workers,
+// memstats, cmdline). Он отделен от API, чтобы метрики не были доступны всем клиентам API;
+// адрес задается server.debug_addr, обычно только на loopback.
+func StartDebugServer(lc fx.Lifecycle, config *config.AppConfig) {
+	if config.ServerConfig.DebugAddr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{
+		Addr:    config.ServerConfig.DebugAddr,
+		Handler: mux,
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			log.Printf("Debug server started on %s", server.Addr)
+			go func() {
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					log.Printf("Debug server ListenAndServe error: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Close()
+		},
+	})
+}
+
+func StartIdempotencyPurge(lc fx.Lifecycle, h *web.NotifyHandler) {
+	runUntilStop(lc, "idempotency keys purge", h.RunIdempotencyPurge)
+}
+
 func LoadCacheOnStart(lc fx.Lifecycle, c *redis.RedisService, repo redis.StorageProvider, cfg *config.AppConfig) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -74,46 +112,53 @@ func LoadCacheOnStart(lc fx.Lifecycle, c *redis.RedisService, repo redis.Storage
 }
 
 func StartRabitProducer(lc fx.Lifecycle, r *rabbit.RabbitService) {
-	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			log.Println("Start Rabbit Producer...")
-			producerCtx, cancel := context.WithCancel(context.Background())
-			go r.UploadFromDB(producerCtx)
-
-			lc.Append(fx.Hook{
-				OnStop: func(ctx context.Context) error {
-					log.Println("Stopping Rabit producer")
-					cancel() // отменяем consumer
-					return nil
-				},
-			})
-			log.Println("Rabbit Producer started successfully")
-			return nil
-		},
+	runUntilStop(lc, "Rabbit producer", func(ctx context.Context) {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.RunLeaseReaper(ctx)
+		}()
+		r.UploadFromDB(ctx)
+		wg.Wait()
 	})
 }
 
 func StartRabbitConsumer(lc fx.Lifecycle, r *consumer.RabbitConsumerService) {
+	runUntilStop(lc, "Rabbit consumer", r.Start)
+}
+
+func StartDeadLetterConsumer(lc fx.Lifecycle, r *consumer.DeadLetterConsumer) {
+	runUntilStop(lc, "dead-letter consumer", r.Start)
+}
+
+// runUntilStop запускает run в отдельной горутине на время работы приложения. OnStop отменяет
+// контекст и ждет, пока run вернется, так что хуки, добавленные раньше (закрытие отправителей и БД),
+// выполняются уже после остановки. Оба хука регистрируются сразу: OnStop, добавленный из OnStart,
+// fx при остановке не вызывает.
+func runUntilStop(lc fx.Lifecycle, name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
 	lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			log.Println("Start Rabbit Consumer...")
-
-			// Используем отдельный контекст для долгоживущей горутины
-			consumerCtx, cancel := context.WithCancel(context.Background())
-			go r.Start(consumerCtx)
-
-			// Сохраняем cancel для корректного завершения
-			lc.Append(fx.Hook{
-				OnStop: func(ctx context.Context) error {
-					log.Println("Stopping Rabbit Consumer...")
-					cancel() // отменяем consumer
-					return nil
-				},
-			})
-
-			log.Println("Rabbit Consumer started successfully")
+		OnStart: func(context.Context) error {
+			log.Printf("Start %s...", name)
+			go func() {
+				defer close(done)
+				run(ctx)
+			}()
+			log.Printf("%s started successfully", name)
 			return nil
 		},
+		OnStop: func(stopCtx context.Context) error {
+			log.Printf("Stopping %s...", name)
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
 	})
 }
 
@@ -130,3 +175,20 @@ func ClosePostgresOnStop(lc fx.Lifecycle, postgres *db.Postgres) {
 		},
 	})
 }
+
+// CloseSendersOnStop закрывает соединения отправителей. Хуки OnStop выполняются в обратном
+// порядке, поэтому вызывать ее нужно до StartRabbitConsumer: его OnStop дожидается воркеров
+// (см. runUntilStop), и пул SMTP закрывается только после последней отправки.
+func CloseSendersOnStop(lc fx.Lifecycle, registry *sender.SenderRegistry) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			log.Println("Closing senders...")
+			if err := registry.Close(); err != nil {
+				log.Printf("Failed to close senders: %v", err)
+				return err
+			}
+			log.Println("Senders closed successfully")
+			return nil
+		},
+	})
+}
@@ -0,0 +1,49 @@
+package di
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunUntilStopDrainsBeforeEarlierHooks(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+
+	var drained atomic.Bool
+	var drainedAtClose bool
+	// так регистрируется CloseSendersOnStop: раньше консьюмера, значит, останавливается позже
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			drainedAtClose = drained.Load()
+			return nil
+		},
+	})
+	runUntilStop(lc, "consumer", func(ctx context.Context) {
+		<-ctx.Done()
+		// воркер дописывает начатую отправку
+		time.Sleep(50 * time.Millisecond)
+		drained.Store(true)
+	})
+
+	lc.RequireStart()
+	lc.RequireStop()
+	assert.True(t, drainedAtClose)
+}
+
+func TestRunUntilStopGivesUpOnStopTimeout(t *testing.T) {
+	lc := fxtest.NewLifecycle(t)
+	release := make(chan struct{})
+	defer close(release)
+	runUntilStop(lc, "consumer", func(ctx context.Context) {
+		<-release
+	})
+
+	lc.RequireStart()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, lc.Stop(ctx), context.DeadlineExceeded)
+}
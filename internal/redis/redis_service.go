@@ -4,16 +4,19 @@ import (
 	"context"
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
+	"encoding/json"
 	"errors"
 	"fmt"
 	wbredis "github.com/wb-go/wbf/redis"
 	"github.com/wb-go/wbf/retry"
 	wbzlog "github.com/wb-go/wbf/zlog"
+	"time"
 )
 
 type RedisService struct {
 	client *wbredis.Client
 	cfg    *config.RetrysConfig
+	ttl    time.Duration
 }
 
 type StorageProvider interface {
@@ -24,7 +27,7 @@ func NewRedisService(cfg *config.AppConfig) (*RedisService, error) {
 	redisAddr := fmt.Sprintf("%s:%d", cfg.RedisConfig.Host, cfg.RedisConfig.Port)
 	client := wbredis.New(redisAddr, cfg.RedisConfig.Password, cfg.RedisConfig.DB)
 	wbzlog.Logger.Info().Msg("Connected to Redis")
-	return &RedisService{client: client, cfg: &cfg.RetrysConfig}, nil
+	return &RedisService{client: client, cfg: &cfg.RetrysConfig, ttl: cfg.RedisConfig.TTL}, nil
 }
 
 func (r *RedisService) LoadCache(cfg *config.AppConfig, repo StorageProvider) error {
@@ -46,21 +49,25 @@ func (r *RedisService) LoadCache(cfg *config.AppConfig, repo StorageProvider) er
 
 func (r *RedisService) GetNotification(id string) (*app.Notification, error) {
 	ctx := context.Background()
-	status, err := r.client.GetWithRetry(ctx, retry.Strategy{Attempts: r.cfg.Attempts, Delay: r.cfg.Delay, Backoff: r.cfg.Backoffs}, id)
+	value, err := r.client.GetWithRetry(ctx, retry.Strategy{Attempts: r.cfg.Attempts, Delay: r.cfg.Delay, Backoff: r.cfg.Backoffs}, id)
 	if err != nil {
 		if errors.Is(err, wbredis.NoMatches) {
 			// Ключ просто отсутствует
 			wbzlog.Logger.Debug().
 				Str("id", id).
-				Msg("Notification status not found in Redis")
+				Msg("Notification not found in Redis")
 			return nil, nil
 		}
-		wbzlog.Logger.Warn().Err(err).Msg("Failed to get status by id")
+		wbzlog.Logger.Warn().Err(err).Msg("Failed to get notification by id")
 		return nil, err
 	}
 	var notif app.Notification
-	notif.Status = app.StatusType(status)
-	wbzlog.Logger.Debug().Str("id", id).Str("status", status).Msg("Fetched notification status from Redis")
+	if err := json.Unmarshal([]byte(value), &notif); err != nil {
+		// старый формат кэша хранил только статус — считаем это промахом
+		wbzlog.Logger.Debug().Str("id", id).Msg("Cached value is not a notification, ignoring")
+		return nil, nil
+	}
+	wbzlog.Logger.Debug().Str("id", id).Str("status", string(notif.Status)).Msg("Fetched notification from Redis")
 	return &notif, nil
 }
 
@@ -81,13 +88,20 @@ func (r *RedisService) DeleteNotification(id string) error {
 	return nil
 }
 
+// SaveNotification кладет в кэш уведомление целиком на redis.ttl (0 — без срока жизни).
 func (r *RedisService) SaveNotification(notification *app.Notification) error {
 	id := notification.ID.String()
-	status := notification.Status
+	value, err := json.Marshal(notification)
+	if err != nil {
+		wbzlog.Logger.Warn().Err(err).Msg("Failed to marshal notification for cache")
+		return err
+	}
 	ctx := context.Background()
-	err := r.client.SetWithRetry(ctx, retry.Strategy{Attempts: r.cfg.Attempts, Delay: r.cfg.Delay, Backoff: r.cfg.Backoffs}, id, string(status))
+	err = retry.Do(func() error {
+		return r.client.SetWithExpiration(ctx, id, value, r.ttl)
+	}, retry.Strategy{Attempts: r.cfg.Attempts, Delay: r.cfg.Delay, Backoff: r.cfg.Backoffs})
 	if err != nil {
-		wbzlog.Logger.Warn().Err(err).Msg("Failed to set status by id")
+		wbzlog.Logger.Warn().Err(err).Msg("Failed to set notification by id")
 		return err
 	}
 	return nil
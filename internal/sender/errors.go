@@ -0,0 +1,80 @@
+package sender
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrRecipientUnreachable — получатель недоступен и не станет доступен сам: заблокировал бота,
+	// чат удален или не существует.
+	ErrRecipientUnreachable = errors.New("recipient is unreachable")
+	// ErrInvalidContent — получатель отверг содержимое сообщения (разметку, кнопки, длину).
+	ErrInvalidContent = errors.New("invalid message content")
+)
+
+// PermanentError — ошибка отправки, которую бессмысленно повторять: консьюмер сразу
+// помечает уведомление как failed, не расходуя оставшиеся попытки.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent сообщает, что err — PermanentError.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
+
+// RetryableError — временная ошибка отправки, после которой получатель просит подождать
+// не меньше RetryAfter (например, ответ 429 с заголовком Retry-After).
+type RetryableError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryableError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v (retry after %s)", e.Err, e.RetryAfter)
+	}
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter возвращает задержку, которую запросил получатель, если err — RetryableError.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryable *RetryableError
+	if errors.As(err, &retryable) && retryable.RetryAfter > 0 {
+		return retryable.RetryAfter, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter разбирает заголовок Retry-After: число секунд или HTTP-дата.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
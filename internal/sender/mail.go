@@ -3,37 +3,66 @@ package sender
 import (
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
-	"fmt"
-	"net/smtp"
+	"net/mail"
+	"time"
 )
 
 type EmailChannel struct {
-	smtpHost  string
-	smtpPort  int
-	smtpEmail string
-	smtp      string
+	pool *SMTPPool
+	from mail.Address
 }
 
-func NewEmailChannel(cfg *config.AppConfig) *EmailChannel {
-	return &EmailChannel{
-		smtpHost:  cfg.MailConfig.SMTPHost,
-		smtpPort:  cfg.MailConfig.SMTPPort,
-		smtpEmail: cfg.MailConfig.SMTPEmail,
-		smtp:      cfg.MailConfig.SMTPPassword,
+func init() {
+	Register(app.ChannelSpec{Name: app.Email, MaxMessageLength: 10000, ValidRecipient: app.ValidEmail, RichContent: true},
+		func(cfg *config.AppConfig, _ StorageProvider) (Sender, error) {
+			if cfg.MailConfig.SMTPHost == "" {
+				return nil, ErrChannelDisabled
+			}
+			return NewEmailChannel(cfg)
+		})
+}
+
+func NewEmailChannel(cfg *config.AppConfig) (*EmailChannel, error) {
+	mc := cfg.MailConfig
+	pool, err := NewSMTPPool(SMTPConfig{
+		Host:                mc.SMTPHost,
+		Port:                mc.SMTPPort,
+		Username:            mc.SMTPEmail,
+		Password:            mc.SMTPPassword,
+		TLS:                 mc.TLS,
+		Auth:                mc.Auth,
+		PoolSize:            mc.PoolSize,
+		MaxMessagesPerConn:  mc.MaxMessagesPerConn,
+		IdleTimeout:         mc.IdleTimeout,
+		HealthCheckInterval: mc.HealthCheckInterval,
+		DialTimeout:         mc.DialTimeout,
+		Timeout:             mc.Timeout,
+	})
+	if err != nil {
+		return nil, err
 	}
+
+	from := mc.From
+	if from == "" {
+		from = mc.SMTPEmail
+	}
+	return &EmailChannel{
+		pool: pool,
+		from: mail.Address{Name: mc.FromName, Address: from},
+	}, nil
 }
 
+// Send — реализация интерфейса Sender. Адрес конверта (MAIL FROM) совпадает с заголовком From,
+// а не с логином SMTP: при auth: none логина может не быть вовсе.
 func (s *EmailChannel) Send(notification *app.Notification) error {
-	auth := smtp.PlainAuth("", s.smtpEmail, s.smtp, s.smtpHost)
-	to := []string{notification.Recipient}
-	msg := []byte("To: " + notification.Recipient + "\r\n" +
-		"Subject: Notification" + "\r\n" +
-		"\r\n" +
-		notification.Message + "\r\n")
-	addr := s.smtpHost + ":" + fmt.Sprint(s.smtpPort)
-	err := smtp.SendMail(addr, auth, s.smtpEmail, to, msg)
+	msg, err := BuildEmail(s.from, notification, time.Now())
 	if err != nil {
 		return err
 	}
-	return nil
+	return s.pool.Send(s.from.Address, []string{notification.Recipient}, msg)
+}
+
+// Close закрывает соединения SMTP-пула.
+func (s *EmailChannel) Close() error {
+	return s.pool.Close()
 }
@@ -0,0 +1,160 @@
+package sender
+
+import (
+	"bytes"
+	"delayedNotifier/internal/app"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// defaultEmailSubject — тема письма, если в уведомлении она не задана.
+const defaultEmailSubject = "Notification"
+
+// mimePart — часть MIME-сообщения: заголовки части и уже закодированное тело.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// BuildEmail собирает письмо по RFC 5322 и MIME. Структура зависит от содержимого уведомления:
+//
+//	text/plain                                  — только текст
+//	multipart/alternative (text, html)          — есть HTML-версия
+//	multipart/mixed (текст или alternative, вложения…) — есть вложения
+//
+// Текст кодируется quoted-printable в UTF-8, вложения — base64, заголовки с не-ASCII
+// символами — encoded-word (RFC 2047), имена файлов — по RFC 2231.
+func BuildEmail(from mail.Address, notification *app.Notification, now time.Time) ([]byte, error) {
+	root, err := textPart("text/plain", notification.Message)
+	if err != nil {
+		return nil, err
+	}
+	if notification.HTML != "" {
+		html, err := textPart("text/html", notification.HTML)
+		if err != nil {
+			return nil, err
+		}
+		if root, err = multipartPart("alternative", root, html); err != nil {
+			return nil, err
+		}
+	}
+	if len(notification.Attachments) > 0 {
+		parts := []mimePart{root}
+		for _, a := range notification.Attachments {
+			parts = append(parts, attachmentPart(a))
+		}
+		if root, err = multipartPart("mixed", parts...); err != nil {
+			return nil, err
+		}
+	}
+
+	subject := notification.Subject
+	if subject == "" {
+		subject = defaultEmailSubject
+	}
+
+	var msg bytes.Buffer
+	writeHeader(&msg, "From", foldEncodedWords(from.String()))
+	writeHeader(&msg, "To", (&mail.Address{Address: notification.Recipient}).String())
+	writeHeader(&msg, "Subject", foldEncodedWords(mime.BEncoding.Encode("utf-8", subject)))
+	writeHeader(&msg, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&msg, "Message-ID", messageID(notification, from.Address))
+	writeHeader(&msg, "MIME-Version", "1.0")
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := root.header.Get(key); value != "" {
+			writeHeader(&msg, key, value)
+		}
+	}
+	msg.WriteString("\r\n")
+	msg.Write(root.body)
+	return msg.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// foldEncodedWords переносит заголовок между encoded-word (каждое не длиннее 75 символов),
+// чтобы длинная тема на кириллице не превращалась в одну строку в сотни символов.
+func foldEncodedWords(value string) string {
+	return strings.ReplaceAll(value, "?= =?", "?=\r\n =?")
+}
+
+// messageID строится из ID уведомления: повторные попытки отправки дают тот же Message-ID,
+// и почтовые клиенты склеивают дубликаты.
+func messageID(notification *app.Notification, from string) string {
+	domain := "localhost"
+	if at := strings.LastIndexByte(from, '@'); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	return fmt.Sprintf("<%s@%s>", notification.ID, domain)
+}
+
+func textPart(contentType, text string) (mimePart, error) {
+	var body bytes.Buffer
+	w := quotedprintable.NewWriter(&body)
+	if _, err := w.Write([]byte(text)); err != nil {
+		return mimePart{}, err
+	}
+	if err := w.Close(); err != nil {
+		return mimePart{}, err
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return mimePart{header: header, body: body.Bytes()}, nil
+}
+
+func multipartPart(subtype string, parts ...mimePart) (mimePart, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, p := range parts {
+		pw, err := w.CreatePart(p.header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if _, err := pw.Write(p.body); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return mimePart{}, err
+	}
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": w.Boundary()}))
+	return mimePart{header: header, body: body.Bytes()}, nil
+}
+
+func attachmentPart(a app.Attachment) mimePart {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+	params["name"] = a.Filename
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	// base64 строками по 76 символов (RFC 2045)
+	encoded := base64.StdEncoding.EncodeToString(a.Content)
+	var body bytes.Buffer
+	for len(encoded) > 76 {
+		body.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	body.WriteString(encoded + "\r\n")
+	return mimePart{header: header, body: body.Bytes()}
+}
@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFrom = mail.Address{Name: "Сервис уведомлений", Address: "noreply@example.com"}
+
+func readEmail(t *testing.T, raw []byte) *mail.Message {
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	assert.NoError(t, err)
+	return msg
+}
+
+func readAll(t *testing.T, r io.Reader) string {
+	b, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestBuildEmailPlainText(t *testing.T) {
+	n := &app.Notification{ID: uuid.New(), Recipient: "user@example.com", Message: "Привет! Встреча в 10:00"}
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	raw, err := BuildEmail(testFrom, n, now)
+	assert.NoError(t, err)
+	msg := readEmail(t, raw)
+
+	from, err := msg.Header.AddressList("From")
+	assert.NoError(t, err)
+	assert.Equal(t, testFrom, *from[0])
+	assert.Equal(t, "<user@example.com>", msg.Header.Get("To"))
+	assert.Equal(t, "<"+n.ID.String()+"@example.com>", msg.Header.Get("Message-ID"))
+	date, err := msg.Header.Date()
+	assert.NoError(t, err)
+	assert.True(t, now.Equal(date))
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, defaultEmailSubject, subject)
+
+	assert.Equal(t, "text/plain; charset=utf-8", msg.Header.Get("Content-Type"))
+	assert.Equal(t, "quoted-printable", msg.Header.Get("Content-Transfer-Encoding"))
+	assert.Equal(t, n.Message, readAll(t, quotedprintable.NewReader(msg.Body)))
+}
+
+func TestBuildEmailLongSubjectIsFolded(t *testing.T) {
+	n := &app.Notification{ID: uuid.New(), Recipient: "user@example.com", Message: "hi", Subject: strings.Repeat("Тема письма ", 10)}
+
+	raw, err := BuildEmail(testFrom, n, time.Now())
+	assert.NoError(t, err)
+	// на строке не больше имени заголовка и одного encoded-word
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		assert.LessOrEqual(t, len(line), len("Subject: ")+75)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(readEmail(t, raw).Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, n.Subject, subject)
+}
+
+func TestBuildEmailMultipart(t *testing.T) {
+	pdf := []byte(strings.Repeat("%PDF-1.4 binary\x00\xff", 20))
+	n := &app.Notification{
+		ID:          uuid.New(),
+		Recipient:   "user@example.com",
+		Subject:     "Отчет",
+		Message:     "Отчет во вложении",
+		HTML:        "<p>Отчет во <b>вложении</b></p>",
+		Attachments: []app.Attachment{{Filename: "отчет.pdf", ContentType: "application/pdf", Content: pdf}},
+	}
+
+	raw, err := BuildEmail(testFrom, n, time.Now())
+	assert.NoError(t, err)
+	msg := readEmail(t, raw)
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+	mixed := multipart.NewReader(msg.Body, params["boundary"])
+
+	// первая часть — alternative с текстом и HTML
+	body, err := mixed.NextPart()
+	assert.NoError(t, err)
+	mediaType, params, err = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	alternative := multipart.NewReader(body, params["boundary"])
+
+	text, err := alternative.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", text.Header.Get("Content-Type"))
+	assert.Equal(t, n.Message, readAll(t, text)) // NextPart сам снимает quoted-printable
+	html, err := alternative.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", html.Header.Get("Content-Type"))
+	assert.Equal(t, n.HTML, readAll(t, html))
+	_, err = alternative.NextPart()
+	assert.Equal(t, io.EOF, err)
+
+	// вторая — вложение с именем в RFC 2231
+	attachment, err := mixed.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "отчет.pdf", attachment.FileName())
+	assert.Equal(t, "base64", attachment.Header.Get("Content-Transfer-Encoding"))
+	encoded := readAll(t, attachment)
+	for _, line := range strings.Split(strings.TrimSpace(encoded), "\r\n") {
+		assert.LessOrEqual(t, len(line), 76)
+	}
+	_, err = mixed.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
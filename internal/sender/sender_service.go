@@ -3,6 +3,11 @@ package sender
 import (
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
+	"errors"
+	"fmt"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"io"
+	"sync"
 )
 
 type SenderRegistry struct {
@@ -13,11 +18,87 @@ type Sender interface {
 	Send(notification *app.Notification) error
 }
 
-func NewSenderRegistry(cfg *config.AppConfig) *SenderRegistry {
-	senders := make(map[app.ChannelType]Sender)
+// StorageProvider — данные, которые нужны отправителям отдельных каналов (push-подписки,
+// подписчики Telegram-бота).
+type StorageProvider interface {
+	PushSubscriptionStorage
+	TelegramSubscriberStorage
+}
+
+type PushSubscriptionStorage interface {
+	GetPushSubscriptions(recipient string) ([]*app.PushSubscription, error)
+	DeletePushSubscriptionByEndpoint(endpoint string) (bool, error)
+}
+
+type TelegramSubscriberStorage interface {
+	SaveTelegramSubscriber(subscriber *app.TelegramSubscriber) error
+	GetTelegramSubscriberByUsername(username string) (*app.TelegramSubscriber, error)
+	DeleteTelegramSubscriber(chatID int64) (bool, error)
+}
+
+// Factory создает отправителя канала из конфига. ErrChannelDisabled означает,
+// что канал не настроен и запускать его не нужно.
+type Factory func(cfg *config.AppConfig, repo StorageProvider) (Sender, error)
+
+// recipientValidator и messageValidator — отправители, правила проверки которых зависят
+// от их настроек (политика хостов, предел SMS-сегментов). Реестр передает их в app.ChannelSpec канала.
+type recipientValidator interface {
+	ValidRecipient(recipient string) error
+}
+
+type messageValidator interface {
+	ValidMessage(message string) error
+}
+
+// ErrChannelDisabled — канал зарегистрирован, но выключен в конфиге.
+var ErrChannelDisabled = errors.New("channel disabled")
+
+var (
+	factoriesMu sync.Mutex
+	factories   = map[app.ChannelType]Factory{}
+)
+
+// Register регистрирует канал доставки: правила проверки уведомлений (app.ChannelSpec)
+// и фабрику отправителя. Вызывается из init() файла канала; настройки нового канала
+// принято читать из секции channels.<имя> (config.AppConfig.ChannelSection).
+func Register(spec app.ChannelSpec, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
 
-	senders[app.Telegram] = NewTelegramChannel(cfg)
-	senders[app.Email] = NewEmailChannel(cfg)
+	if factory == nil {
+		panic(fmt.Sprintf("sender: nil factory for channel %q", spec.Name))
+	}
+	app.RegisterChannel(spec)
+	factories[spec.Name] = factory
+}
+
+// NewSenderRegistry создает отправителей всех зарегистрированных каналов. Канал, который
+// не удалось создать, пропускается: его уведомления консьюмер отметит как failed.
+func NewSenderRegistry(cfg *config.AppConfig, repo StorageProvider) *SenderRegistry {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	senders := make(map[app.ChannelType]Sender, len(factories))
+	for name, factory := range factories {
+		s, err := factory(cfg, repo)
+		if errors.Is(err, ErrChannelDisabled) {
+			wbzlog.Logger.Info().Str("channel", string(name)).Msg("Channel is disabled")
+			continue
+		}
+		if err != nil {
+			wbzlog.Logger.Error().Err(err).Str("channel", string(name)).Msg("Failed to create channel sender")
+			continue
+		}
+		senders[name] = s
+		app.UpdateChannel(name, func(spec *app.ChannelSpec) {
+			if v, ok := s.(recipientValidator); ok {
+				spec.ValidRecipient = v.ValidRecipient
+			}
+			if v, ok := s.(messageValidator); ok {
+				spec.ValidMessage = v.ValidMessage
+			}
+		})
+	}
 	return &SenderRegistry{
 		senders: senders,
 	}
@@ -26,3 +107,34 @@ func NewSenderRegistry(cfg *config.AppConfig) *SenderRegistry {
 func (r *SenderRegistry) All() map[app.ChannelType]Sender {
 	return r.senders
 }
+
+// Close освобождает ресурсы отправителей, которые их держат (например, пул SMTP-соединений).
+func (r *SenderRegistry) Close() error {
+	var errs []error
+	for name, s := range r.senders {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidPushEndpoint проверяет endpoint новой push-подписки по политике хостов канала webpush
+// (или политике по умолчанию, если канал выключен): сервис будет отправлять на него запросы.
+func (r *SenderRegistry) ValidPushEndpoint(endpoint string) error {
+	if w, ok := r.senders[WebPush].(*WebPushChannel); ok {
+		return w.ValidEndpoint(endpoint)
+	}
+	return defaultHostPolicy.validURL(endpoint)
+}
+
+// VAPIDPublicKey возвращает открытый ключ VAPID канала webpush; false — канал выключен.
+func (r *SenderRegistry) VAPIDPublicKey() (string, bool) {
+	w, ok := r.senders[WebPush].(*WebPushChannel)
+	if !ok {
+		return "", false
+	}
+	return w.publicKey, true
+}
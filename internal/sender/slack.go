@@ -0,0 +1,185 @@
+package sender
+
+import (
+	"bytes"
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Slack — сообщение в incoming webhook Slack (или совместимого мессенджера: Mattermost, Rocket.Chat).
+// Получатель — URL вебхука.
+const Slack app.ChannelType = "slack"
+
+const (
+	// slackMaxHeader — предел текста блока header в Block Kit
+	slackMaxHeader = 150
+	// slackMaxSection — предел текста блока section в Block Kit
+	slackMaxSection = 3000
+)
+
+// SlackConfig — секция channels.slack. AllowedHosts, DeniedHosts и AllowPrivateNetworks — та же
+// политика хостов, что у канала webhook: вебхук на внутреннем адресе (например, Mattermost
+// в частной сети) нужно разрешить явно.
+type SlackConfig struct {
+	Timeout              time.Duration `mapstructure:"timeout" default:"10s"`
+	AllowedHosts         []string      `mapstructure:"allowed_hosts"`
+	DeniedHosts          []string      `mapstructure:"denied_hosts"`
+	AllowPrivateNetworks bool          `mapstructure:"allow_private_networks" default:"false"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+type slackMessage struct {
+	// Text показывается в пуш-уведомлениях и клиентах без поддержки блоков
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type SlackChannel struct {
+	client *http.Client
+	hosts  *hostPolicy
+}
+
+func init() {
+	Register(app.ChannelSpec{Name: Slack, MaxMessageLength: 40000, ValidRecipient: defaultHostPolicy.validURL},
+		func(cfg *config.AppConfig, _ StorageProvider) (Sender, error) {
+			var sc SlackConfig
+			if _, err := cfg.ChannelSection(string(Slack), &sc); err != nil {
+				return nil, err
+			}
+			return NewSlackChannel(sc)
+		})
+}
+
+func NewSlackChannel(cfg SlackConfig) (*SlackChannel, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	hosts, err := newHostPolicy(cfg.AllowedHosts, cfg.DeniedHosts, cfg.AllowPrivateNetworks)
+	if err != nil {
+		return nil, err
+	}
+	return &SlackChannel{client: hosts.client(cfg.Timeout), hosts: hosts}, nil
+}
+
+// ValidRecipient проверяет URL вебхука по политике хостов канала; реестр отдает ее API.
+func (s *SlackChannel) ValidRecipient(recipient string) error {
+	return s.hosts.validURL(recipient)
+}
+
+// Send — реализация интерфейса Sender. Запрещенный политикой хост — постоянная ошибка.
+// Ответ 429 превращается в RetryableError
+// с задержкой из Retry-After, остальные 4xx — в PermanentError: повтор не исправит ни тело
+// запроса (400 invalid_payload), ни удаленный или отозванный вебхук (403, 404 no_service).
+func (s *SlackChannel) Send(notification *app.Notification) error {
+	body, err := json.Marshal(slackBlocks(notification.Message))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(notification.Recipient, "application/json", bytes.NewReader(body))
+	if err != nil {
+		if errors.Is(err, ErrHostNotAllowed) {
+			return &PermanentError{Err: fmt.Errorf("%w: %w", ErrRecipientUnreachable, err)}
+		}
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	// Slack отвечает кодом ошибки текстом: invalid_payload, channel_not_found, no_service…
+	err = fmt.Errorf("slack responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if retryAfter == 0 {
+			retryAfter = time.Second
+		}
+		return &RetryableError{Err: err, RetryAfter: retryAfter}
+	}
+	if resp.StatusCode == http.StatusBadRequest {
+		return &PermanentError{Err: fmt.Errorf("%w: %v", ErrInvalidContent, err)}
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		return &PermanentError{Err: fmt.Errorf("%w: %v", ErrRecipientUnreachable, err)}
+	}
+	return err
+}
+
+// slackBlocks раскладывает текст уведомления в Block Kit: короткая первая строка, за которой
+// следует остальной текст, становится заголовком, абзацы — блоками section в mrkdwn.
+func slackBlocks(message string) slackMessage {
+	msg := slackMessage{Text: escapeSlack(message)}
+
+	body := strings.TrimSpace(message)
+	if title, rest, ok := strings.Cut(body, "\n"); ok && utf8.RuneCountInString(title) <= slackMaxHeader && strings.TrimSpace(rest) != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: strings.TrimSpace(title)}})
+		body = strings.TrimSpace(rest)
+	}
+
+	// абзацы упаковываются в секции целиком, пока секция не превысит предел Block Kit
+	var section strings.Builder
+	flush := func() {
+		if section.Len() > 0 {
+			msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: section.String()}})
+			section.Reset()
+		}
+	}
+	for _, paragraph := range strings.Split(body, "\n\n") {
+		paragraph = escapeSlack(strings.TrimSpace(paragraph))
+		if paragraph == "" {
+			continue
+		}
+		for _, chunk := range splitRunes(paragraph, slackMaxSection) {
+			if section.Len() > 0 && utf8.RuneCountInString(section.String())+2+utf8.RuneCountInString(chunk) > slackMaxSection {
+				flush()
+			}
+			if section.Len() > 0 {
+				section.WriteString("\n\n")
+			}
+			section.WriteString(chunk)
+		}
+	}
+	flush()
+	return msg
+}
+
+// escapeSlack экранирует управляющие символы разметки Slack.
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// splitRunes режет строку на части не длиннее limit символов.
+func splitRunes(s string, limit int) []string {
+	var parts []string
+	for utf8.RuneCountInString(s) > limit {
+		cut := 0
+		for i := 0; i < limit; i++ {
+			_, size := utf8.DecodeRuneInString(s[cut:])
+			cut += size
+		}
+		parts = append(parts, s[:cut])
+		s = s[cut:]
+	}
+	return append(parts, s)
+}
@@ -0,0 +1,138 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackSendPostsBlocks(t *testing.T) {
+	var got slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "Deploy finished\nAll *green* <3\n\nsee dashboard"}
+	assert.NoError(t, newTestSlackChannel(t).Send(n))
+
+	assert.Len(t, got.Blocks, 2)
+	assert.Equal(t, "header", got.Blocks[0].Type)
+	assert.Equal(t, "Deploy finished", got.Blocks[0].Text.Text)
+	assert.Equal(t, "section", got.Blocks[1].Type)
+	assert.Equal(t, "mrkdwn", got.Blocks[1].Text.Type)
+	assert.Equal(t, "All *green* &lt;3\n\nsee dashboard", got.Blocks[1].Text.Text)
+	assert.Contains(t, got.Text, "Deploy finished")
+}
+
+func TestSlackSendRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("rate_limited"))
+	}))
+	defer srv.Close()
+
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "hi"}
+	err := newTestSlackChannel(t).Send(n)
+
+	var retryable *RetryableError
+	assert.True(t, errors.As(err, &retryable))
+	retryAfter, ok := RetryAfter(err)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+}
+
+func TestSlackSendPermanentError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("no_service"))
+	}))
+	defer srv.Close()
+
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "hi"}
+	err := newTestSlackChannel(t).Send(n)
+
+	assert.ErrorContains(t, err, "no_service")
+	var permanent *PermanentError
+	assert.True(t, errors.As(err, &permanent))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+	_, ok := RetryAfter(err)
+	assert.False(t, ok)
+}
+
+func TestSlackSendInvalidPayload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid_payload"))
+	}))
+	defer srv.Close()
+
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "hi"}
+	err := newTestSlackChannel(t).Send(n)
+
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrInvalidContent)
+}
+
+func TestSlackSendServerErrorIsRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "hi"}
+	err := newTestSlackChannel(t).Send(n)
+
+	assert.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestSlackBlocksSplitLongText(t *testing.T) {
+	msg := slackBlocks(strings.Repeat("a", slackMaxSection+10))
+
+	assert.Len(t, msg.Blocks, 2)
+	assert.Equal(t, slackMaxSection, len(msg.Blocks[0].Text.Text))
+	assert.Equal(t, 10, len(msg.Blocks[1].Text.Text))
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	now := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 90*time.Second, parseRetryAfter("Tue, 01 Jan 2030 10:01:30 GMT", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+}
+
+// newTestSlackChannel разрешает 127.0.0.1, на котором слушает httptest.
+func newTestSlackChannel(t *testing.T) *SlackChannel {
+	s, err := NewSlackChannel(SlackConfig{AllowedHosts: loopback})
+	assert.NoError(t, err)
+	return s
+}
+
+func TestSlackSendRejectsInternalHosts(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	s, err := NewSlackChannel(SlackConfig{})
+	assert.NoError(t, err)
+	n := &app.Notification{ID: uuid.New(), Channel: Slack, Recipient: srv.URL, Message: "hi"}
+
+	err = s.Send(n)
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+	assert.True(t, IsPermanent(err))
+	assert.False(t, called)
+	assert.ErrorIs(t, s.ValidRecipient(srv.URL), ErrHostNotAllowed)
+}
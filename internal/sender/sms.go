@@ -0,0 +1,175 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SMS — короткое сообщение через HTTP-шлюз оператора. Получатель — номер в формате E.164.
+const SMS = app.SMS
+
+// SMSEncoding — кодировка SMS: от нее зависит, сколько символов помещается в сегмент.
+type SMSEncoding string
+
+const (
+	GSM7 SMSEncoding = "GSM-7"
+	UCS2 SMSEncoding = "UCS-2"
+)
+
+// Вместимость сегмента: одиночное сообщение и часть составного (6 байт занимает заголовок UDH).
+const (
+	gsm7Single = 160
+	gsm7Part   = 153
+	ucs2Single = 70
+	ucs2Part   = 67
+)
+
+// gsm7Basic — основная таблица GSM 03.38 (символ ESC исключен), gsm7Extended — расширенная:
+// каждый ее символ занимает два септета.
+const (
+	gsm7Basic    = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsm7Extended = "\f^{}\\[~]|€"
+)
+
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// SMSMessage — сообщение для шлюза: текст целиком и он же, разбитый на сегменты.
+type SMSMessage struct {
+	To       string
+	Text     string
+	Encoding SMSEncoding
+	Segments []string
+}
+
+// SMSGateway — адаптер конкретного SMS-шлюза.
+type SMSGateway interface {
+	SendSMS(msg SMSMessage) error
+}
+
+type SMSChannel struct {
+	gateway     SMSGateway
+	maxSegments int
+}
+
+func init() {
+	Register(app.ChannelSpec{Name: SMS, MaxMessageLength: 1600, ValidRecipient: validE164, ValidMessage: NewSMSChannel(nil, 0).ValidMessage},
+		func(cfg *config.AppConfig, _ StorageProvider) (Sender, error) {
+			var gc HTTPGatewayConfig
+			ok, err := cfg.ChannelSection(string(SMS), &gc)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || gc.URL == "" {
+				return nil, ErrChannelDisabled
+			}
+			gateway, err := NewHTTPGateway(gc)
+			if err != nil {
+				return nil, err
+			}
+			return NewSMSChannel(gateway, gc.MaxSegments), nil
+		})
+}
+
+func validE164(recipient string) error {
+	if !e164.MatchString(recipient) {
+		return fmt.Errorf("%q is not a phone number in E.164 format (+79991234567)", recipient)
+	}
+	return nil
+}
+
+// NewSMSChannel создает канал поверх шлюза. maxSegments ограничивает длину составного сообщения
+// (0 — 10 сегментов), чтобы случайно длинный текст не превратился в десятки платных SMS.
+func NewSMSChannel(gateway SMSGateway, maxSegments int) *SMSChannel {
+	if maxSegments <= 0 {
+		maxSegments = 10
+	}
+	return &SMSChannel{gateway: gateway, maxSegments: maxSegments}
+}
+
+// ValidMessage проверяет, что текст укладывается в maxSegments сегментов; реестр отдает
+// эту проверку API, и слишком длинное SMS отклоняется при создании уведомления.
+func (s *SMSChannel) ValidMessage(message string) error {
+	encoding, segments := SegmentSMS(message)
+	if len(segments) > s.maxSegments {
+		return fmt.Errorf("sms needs %d %s segments, at most %d allowed", len(segments), encoding, s.maxSegments)
+	}
+	return nil
+}
+
+// Send — реализация интерфейса Sender. Текст длиннее maxSegments сегментов (например, принятый
+// до уменьшения max_segments) не станет короче при повторе, поэтому эта ошибка постоянная.
+func (s *SMSChannel) Send(notification *app.Notification) error {
+	if err := s.ValidMessage(notification.Message); err != nil {
+		return &PermanentError{Err: fmt.Errorf("%w: %v", ErrInvalidContent, err)}
+	}
+	encoding, segments := SegmentSMS(notification.Message)
+	return s.gateway.SendSMS(SMSMessage{
+		To:       notification.Recipient,
+		Text:     notification.Message,
+		Encoding: encoding,
+		Segments: segments,
+	})
+}
+
+// SegmentSMS выбирает кодировку (GSM-7, если все символы есть в таблицах GSM, иначе UCS-2)
+// и разбивает текст на сегменты. Символ из двух септетов или суррогатная пара UTF-16
+// не разрываются между сегментами.
+func SegmentSMS(text string) (SMSEncoding, []string) {
+	encoding, cost := GSM7, gsm7Cost
+	for _, r := range text {
+		if gsm7Cost(r) == 0 {
+			encoding, cost = UCS2, ucs2Cost
+			break
+		}
+	}
+
+	single, part := gsm7Single, gsm7Part
+	if encoding == UCS2 {
+		single, part = ucs2Single, ucs2Part
+	}
+
+	total := 0
+	for _, r := range text {
+		total += cost(r)
+	}
+	if total <= single {
+		return encoding, []string{text}
+	}
+
+	var segments []string
+	var current strings.Builder
+	used := 0
+	for _, r := range text {
+		c := cost(r)
+		if used+c > part {
+			segments = append(segments, current.String())
+			current.Reset()
+			used = 0
+		}
+		current.WriteRune(r)
+		used += c
+	}
+	return encoding, append(segments, current.String())
+}
+
+// gsm7Cost возвращает число септетов символа или 0, если его нет в GSM-7.
+func gsm7Cost(r rune) int {
+	switch {
+	case strings.ContainsRune(gsm7Basic, r):
+		return 1
+	case strings.ContainsRune(gsm7Extended, r):
+		return 2
+	}
+	return 0
+}
+
+// ucs2Cost возвращает число кодовых единиц UTF-16 символа.
+func ucs2Cost(r rune) int {
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
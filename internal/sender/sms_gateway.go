@@ -0,0 +1,148 @@
+package sender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HTTPGatewayConfig — секция channels.sms: HTTP-шлюз, тело запроса к которому задается шаблоном
+// text/template. В шаблоне доступны .To, .From, .Text, .Encoding, .Part и .Parts, функции
+// json (строка в JSON с кавычками) и urlquery.
+type HTTPGatewayConfig struct {
+	URL         string        `mapstructure:"url" default:""`
+	Method      string        `mapstructure:"method" default:"POST"`
+	ContentType string        `mapstructure:"content_type" default:"application/json"`
+	Body        string        `mapstructure:"body"`
+	AuthHeader  string        `mapstructure:"auth_header" default:"Authorization"`
+	AuthValue   string        `mapstructure:"auth_value" default:""`
+	From        string        `mapstructure:"from" default:""`
+	Timeout     time.Duration `mapstructure:"timeout" default:"10s"`
+	// PerSegment — отправлять каждый сегмент отдельным запросом, если шлюз сам не склеивает длинные SMS.
+	// Повтор после ошибки на одном из сегментов отправляет сообщение с первого сегмента заново
+	PerSegment  bool `mapstructure:"per_segment" default:"false"`
+	MaxSegments int  `mapstructure:"max_segments" default:"10"`
+}
+
+// defaultSMSBody — тело запроса, если шаблон не задан.
+const defaultSMSBody = `{"to": {{json .To}}, "from": {{json .From}}, "text": {{json .Text}}}`
+
+type smsTemplateData struct {
+	To       string
+	From     string
+	Text     string
+	Encoding SMSEncoding
+	Part     int // номер сегмента с 1; при отправке целиком — 1
+	Parts    int
+}
+
+// HTTPGateway — SMSGateway для шлюзов с HTTP API.
+type HTTPGateway struct {
+	client      *http.Client
+	url         string
+	method      string
+	contentType string
+	body        *template.Template
+	authHeader  string
+	authValue   string
+	from        string
+	perSegment  bool
+}
+
+func NewHTTPGateway(cfg HTTPGatewayConfig) (*HTTPGateway, error) {
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+	if cfg.Body == "" {
+		cfg.Body = defaultSMSBody
+	}
+	if cfg.AuthHeader == "" {
+		cfg.AuthHeader = "Authorization"
+	}
+	if cfg.AuthValue == "" {
+		cfg.AuthValue = os.Getenv("SMS_GATEWAY_AUTH")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+
+	body, err := template.New("sms").Funcs(template.FuncMap{
+		"json": func(s string) (string, error) {
+			b, err := json.Marshal(s)
+			return string(b), err
+		},
+	}).Option("missingkey=error").Parse(cfg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("sms body template: %w", err)
+	}
+
+	return &HTTPGateway{
+		client:      &http.Client{Timeout: cfg.Timeout},
+		url:         cfg.URL,
+		method:      strings.ToUpper(cfg.Method),
+		contentType: cfg.ContentType,
+		body:        body,
+		authHeader:  cfg.AuthHeader,
+		authValue:   cfg.AuthValue,
+		from:        cfg.From,
+		perSegment:  cfg.PerSegment,
+	}, nil
+}
+
+// SendSMS — реализация интерфейса SMSGateway. В режиме perSegment повтор не идемпотентен:
+// уже принятые шлюзом сегменты при следующей попытке уйдут получателю еще раз.
+func (g *HTTPGateway) SendSMS(msg SMSMessage) error {
+	if !g.perSegment || len(msg.Segments) <= 1 {
+		return g.post(smsTemplateData{To: msg.To, From: g.from, Text: msg.Text, Encoding: msg.Encoding, Part: 1, Parts: len(msg.Segments)})
+	}
+	for i, segment := range msg.Segments {
+		data := smsTemplateData{To: msg.To, From: g.from, Text: segment, Encoding: msg.Encoding, Part: i + 1, Parts: len(msg.Segments)}
+		if err := g.post(data); err != nil {
+			return fmt.Errorf("segment %d/%d: %w", i+1, len(msg.Segments), err)
+		}
+	}
+	return nil
+}
+
+func (g *HTTPGateway) post(data smsTemplateData) error {
+	var body bytes.Buffer
+	if err := g.body.Execute(&body, data); err != nil {
+		return fmt.Errorf("render sms body: %w", err)
+	}
+
+	req, err := http.NewRequest(g.method, g.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", g.contentType)
+	if g.authValue != "" {
+		req.Header.Set(g.authHeader, g.authValue)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("sms gateway responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+		return &RetryableError{Err: err, RetryAfter: retryAfter}
+	}
+	return classifyHTTPStatus(resp.StatusCode, err)
+}
@@ -0,0 +1,188 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeGateway запоминает сообщения вместо отправки.
+type fakeGateway struct {
+	sent []SMSMessage
+	err  error
+}
+
+func (g *fakeGateway) SendSMS(msg SMSMessage) error {
+	if g.err != nil {
+		return g.err
+	}
+	g.sent = append(g.sent, msg)
+	return nil
+}
+
+func newSMS(message string) *app.Notification {
+	return &app.Notification{ID: uuid.New(), Channel: SMS, Recipient: "+79991234567", Message: message}
+}
+
+func TestSegmentSMSGSM7(t *testing.T) {
+	encoding, segments := SegmentSMS(strings.Repeat("a", 160))
+	assert.Equal(t, GSM7, encoding)
+	assert.Len(t, segments, 1)
+
+	_, segments = SegmentSMS(strings.Repeat("a", 161))
+	assert.Len(t, segments, 2)
+	assert.Len(t, segments[0], 153)
+}
+
+func TestSegmentSMSExtendedCharsTakeTwoSeptets(t *testing.T) {
+	// 80 символов € — 160 септетов, ровно одно SMS
+	encoding, segments := SegmentSMS(strings.Repeat("€", 80))
+	assert.Equal(t, GSM7, encoding)
+	assert.Len(t, segments, 1)
+
+	// символ из двух септетов не разрывается на границе сегмента
+	_, segments = SegmentSMS(strings.Repeat("a", 152) + "€" + strings.Repeat("a", 10))
+	assert.Len(t, segments, 2)
+	assert.Equal(t, strings.Repeat("a", 152), segments[0])
+}
+
+func TestSegmentSMSUCS2(t *testing.T) {
+	encoding, segments := SegmentSMS(strings.Repeat("ж", 70))
+	assert.Equal(t, UCS2, encoding)
+	assert.Len(t, segments, 1)
+
+	_, segments = SegmentSMS(strings.Repeat("ж", 71))
+	assert.Len(t, segments, 2)
+	assert.Equal(t, 67, len([]rune(segments[0])))
+
+	// эмодзи — суррогатная пара, занимает две единицы UTF-16
+	_, segments = SegmentSMS(strings.Repeat("ж", 66) + "😀" + strings.Repeat("ж", 5))
+	assert.Len(t, segments, 2)
+	assert.Equal(t, strings.Repeat("ж", 66), segments[0])
+}
+
+func TestSMSChannelSend(t *testing.T) {
+	gateway := &fakeGateway{}
+	ch := NewSMSChannel(gateway, 2)
+
+	assert.NoError(t, ch.Send(newSMS("Встреча в 10:00")))
+	assert.Len(t, gateway.sent, 1)
+	assert.Equal(t, "+79991234567", gateway.sent[0].To)
+	assert.Equal(t, UCS2, gateway.sent[0].Encoding)
+
+	err := ch.Send(newSMS(strings.Repeat("ж", 200)))
+	assert.True(t, IsPermanent(err), "three segments exceed the limit")
+	assert.ErrorIs(t, err, ErrInvalidContent)
+	assert.Len(t, gateway.sent, 1)
+
+	gateway.err = errors.New("gateway down")
+	assert.ErrorContains(t, ch.Send(newSMS("hi")), "gateway down")
+}
+
+func TestSMSRecipientValidation(t *testing.T) {
+	spec, ok := app.LookupChannel(SMS)
+
+	assert.True(t, ok)
+	assert.NoError(t, spec.ValidRecipient("+14155552671"))
+	assert.Error(t, spec.ValidRecipient("89991234567"))
+	assert.Error(t, spec.ValidRecipient("+0123"))
+	assert.Error(t, spec.ValidRecipient("+1 415 555 2671"))
+}
+
+func TestSMSMessageValidation(t *testing.T) {
+	// 700 символов кириллицы — 11 сегментов UCS-2, больше предела по умолчанию: API отвечает 422
+	_, err := app.NewNotification("sms", strings.Repeat("ж", 700), "+79991234567", "2099-01-01T00:00:00Z", "")
+	var errs app.ValidationErrors
+	assert.True(t, errors.As(err, &errs))
+	assert.Equal(t, "message", errs[0].Field)
+	assert.ErrorIs(t, err, app.ErrMessageTooLong)
+
+	_, err = app.NewNotification("sms", strings.Repeat("ж", 600), "+79991234567", "2099-01-01T00:00:00Z", "")
+	assert.NoError(t, err)
+
+	// предел из конфигурации канала
+	ch := NewSMSChannel(&fakeGateway{}, 2)
+	assert.NoError(t, ch.ValidMessage(strings.Repeat("ж", 134)))
+	assert.Error(t, ch.ValidMessage(strings.Repeat("ж", 135)))
+}
+
+func TestHTTPGatewayRendersTemplate(t *testing.T) {
+	var bodies []map[string]any
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("X-Api-Key")
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		assert.NoError(t, json.Unmarshal(raw, &body))
+		bodies = append(bodies, body)
+	}))
+	defer srv.Close()
+
+	gateway, err := NewHTTPGateway(HTTPGatewayConfig{
+		URL:        srv.URL,
+		Body:       `{"phone": {{json .To}}, "sender": {{json .From}}, "msg": {{json .Text}}, "part": {{.Part}}, "parts": {{.Parts}}}`,
+		AuthHeader: "X-Api-Key",
+		AuthValue:  "key-1",
+		From:       "Notifier",
+		PerSegment: true,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, NewSMSChannel(gateway, 0).Send(newSMS(`say "hi" `+strings.Repeat("a", 160))))
+
+	assert.Equal(t, "key-1", auth)
+	assert.Len(t, bodies, 2)
+	assert.Equal(t, "+79991234567", bodies[0]["phone"])
+	assert.Equal(t, "Notifier", bodies[0]["sender"])
+	assert.True(t, strings.HasPrefix(bodies[0]["msg"].(string), `say "hi"`))
+	assert.Equal(t, float64(2), bodies[1]["part"])
+}
+
+func TestHTTPGatewayErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	gateway, err := NewHTTPGateway(HTTPGatewayConfig{URL: srv.URL})
+	assert.NoError(t, err)
+
+	_, retryable := RetryAfter(gateway.SendSMS(SMSMessage{To: "+79991234567", Text: "hi", Segments: []string{"hi"}}))
+	assert.True(t, retryable)
+}
+
+func TestHTTPGatewayClientErrorIsPermanent(t *testing.T) {
+	status := http.StatusUnauthorized
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	gateway, err := NewHTTPGateway(HTTPGatewayConfig{URL: srv.URL})
+	assert.NoError(t, err)
+	msg := SMSMessage{To: "+79991234567", Text: "hi", Segments: []string{"hi"}}
+
+	err = gateway.SendSMS(msg)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+
+	status = http.StatusBadRequest
+	assert.ErrorIs(t, gateway.SendSMS(msg), ErrInvalidContent)
+
+	for _, status = range []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError} {
+		assert.False(t, IsPermanent(gateway.SendSMS(msg)), status)
+	}
+}
+
+func TestNewHTTPGatewayRejectsBadTemplate(t *testing.T) {
+	_, err := NewHTTPGateway(HTTPGatewayConfig{URL: "http://localhost", Body: "{{.To"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,354 @@
+package sender
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Режимы шифрования SMTP-соединения.
+const (
+	SMTPStartTLS = "starttls" // обычное соединение, затем STARTTLS (порт 587); без поддержки сервером — ошибка
+	SMTPImplicit = "implicit" // TLS с первого байта (порт 465)
+	SMTPNoTLS    = "none"     // без шифрования, только для локальных релеев
+)
+
+// Механизмы аутентификации SMTP.
+const (
+	SMTPAuthPlain   = "plain"
+	SMTPAuthLogin   = "login"
+	SMTPAuthCRAMMD5 = "cram-md5"
+	SMTPAuthNone    = "none"
+)
+
+// SMTPConfig — параметры пула SMTP-соединений.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// TLS — SMTPStartTLS, SMTPImplicit или SMTPNoTLS; пусто — implicit для порта 465, иначе starttls
+	TLS string
+	// Auth — SMTPAuthPlain (по умолчанию), SMTPAuthLogin, SMTPAuthCRAMMD5 или SMTPAuthNone
+	Auth string
+	// PoolSize — предел одновременно открытых соединений
+	PoolSize int
+	// MaxMessagesPerConn — после стольких писем соединение закрывается: многие серверы
+	// ограничивают число писем за сессию
+	MaxMessagesPerConn int
+	// IdleTimeout — простаивавшее дольше соединение закрывается, а не переиспользуется
+	IdleTimeout time.Duration
+	// HealthCheckInterval — простаивавшее дольше соединение перед отправкой проверяется командой NOOP
+	HealthCheckInterval time.Duration
+	DialTimeout         time.Duration
+	// Timeout — предельное время одной отправки на открытом соединении
+	Timeout time.Duration
+	// TLSConfig — необязательные настройки TLS (например, свой корневой сертификат)
+	TLSConfig *tls.Config
+}
+
+type smtpConn struct {
+	conn     net.Conn
+	client   *smtp.Client
+	sent     int
+	lastUsed time.Time
+}
+
+// SMTPPool переиспользует аутентифицированные SMTP-соединения между отправками.
+// Соединения открываются по мере надобности, не больше PoolSize одновременно.
+type SMTPPool struct {
+	cfg   SMTPConfig
+	addr  string
+	auth  smtp.Auth
+	slots chan struct{}
+	idle  chan *smtpConn
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewSMTPPool(cfg SMTPConfig) (*SMTPPool, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp host is not set")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = SMTPStartTLS
+		if cfg.Port == 465 {
+			cfg.TLS = SMTPImplicit
+		}
+	}
+	switch cfg.TLS {
+	case SMTPStartTLS, SMTPImplicit, SMTPNoTLS:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", cfg.TLS)
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 4
+	}
+	if cfg.MaxMessagesPerConn <= 0 {
+		cfg.MaxMessagesPerConn = 100
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 5 * time.Minute
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 30 * time.Second
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 10 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Minute
+	}
+
+	auth, err := newSMTPAuth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTPPool{
+		cfg:   cfg,
+		addr:  net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		auth:  auth,
+		slots: make(chan struct{}, cfg.PoolSize),
+		idle:  make(chan *smtpConn, cfg.PoolSize),
+	}, nil
+}
+
+func newSMTPAuth(cfg SMTPConfig) (smtp.Auth, error) {
+	switch strings.ToLower(cfg.Auth) {
+	case "", SMTPAuthPlain:
+		if cfg.Username == "" {
+			return nil, nil
+		}
+		return smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host), nil
+	case SMTPAuthLogin:
+		return &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}, nil
+	case SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(cfg.Username, cfg.Password), nil
+	case SMTPAuthNone:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown smtp auth mechanism %q", cfg.Auth)
+}
+
+// Send отправляет письмо через свободное соединение пула, при необходимости открывая новое.
+// Отказ сервера по конкретному письму (код 4xx/5xx) не закрывает соединение: оно сбрасывается
+// командой RSET и возвращается в пул.
+func (p *SMTPPool) Send(from string, to []string, msg []byte) error {
+	p.slots <- struct{}{}
+	defer func() {
+		<-p.slots
+	}()
+
+	c, err := p.get()
+	if err != nil {
+		return err
+	}
+	err = p.deliver(c, from, to, msg)
+	p.put(c, err)
+	return classifySMTPError(err)
+}
+
+// classifySMTPError делает постоянными отказы сервера с кодом 5xx (нет такого ящика, письмо
+// отклонено): повтор получит тот же ответ. Коды 4xx и сетевые ошибки остаются временными.
+func classifySMTPError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 && protoErr.Code < 600 {
+		return &PermanentError{Err: fmt.Errorf("%w: %w", ErrRecipientUnreachable, protoErr)}
+	}
+	return err
+}
+
+// Close закрывает простаивающие соединения; занятые закрываются по завершении отправки.
+func (p *SMTPPool) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	for {
+		select {
+		case c := <-p.idle:
+			p.quit(c)
+		default:
+			return nil
+		}
+	}
+}
+
+// get берет соединение из пула, отбрасывая простаивавшие слишком долго и не ответившие на NOOP.
+func (p *SMTPPool) get() (*smtpConn, error) {
+	for {
+		select {
+		case c := <-p.idle:
+			if p.healthy(c) {
+				return c, nil
+			}
+			_ = c.client.Close()
+		default:
+			return p.dial()
+		}
+	}
+}
+
+func (p *SMTPPool) healthy(c *smtpConn) bool {
+	idle := time.Since(c.lastUsed)
+	if idle > p.cfg.IdleTimeout {
+		return false
+	}
+	if idle > p.cfg.HealthCheckInterval {
+		_ = c.conn.SetDeadline(time.Now().Add(p.cfg.DialTimeout))
+		return c.client.Noop() == nil
+	}
+	return true
+}
+
+func (p *SMTPPool) dial() (*smtpConn, error) {
+	dialer := &net.Dialer{Timeout: p.cfg.DialTimeout}
+	var conn net.Conn
+	var err error
+	if p.cfg.TLS == SMTPImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", p.addr, p.tlsConfig())
+	} else {
+		conn, err = dialer.Dial("tcp", p.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(p.cfg.Timeout))
+
+	client, err := smtp.NewClient(conn, p.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := p.handshake(client); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return &smtpConn{conn: conn, client: client, lastUsed: time.Now()}, nil
+}
+
+// handshake включает STARTTLS и проходит аутентификацию на новом соединении.
+func (p *SMTPPool) handshake(client *smtp.Client) error {
+	if p.cfg.TLS == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", p.addr)
+		}
+		if err := client.StartTLS(p.tlsConfig()); err != nil {
+			return err
+		}
+	}
+	if p.auth == nil {
+		return nil
+	}
+	if ok, _ := client.Extension("AUTH"); !ok {
+		return fmt.Errorf("smtp server %s does not support AUTH", p.addr)
+	}
+	return client.Auth(p.auth)
+}
+
+func (p *SMTPPool) tlsConfig() *tls.Config {
+	cfg := &tls.Config{}
+	if p.cfg.TLSConfig != nil {
+		cfg = p.cfg.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = p.cfg.Host
+	}
+	return cfg
+}
+
+func (p *SMTPPool) deliver(c *smtpConn, from string, to []string, msg []byte) error {
+	_ = c.conn.SetDeadline(time.Now().Add(p.cfg.Timeout))
+
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// put возвращает соединение в пул или закрывает его: после сетевой ошибки, по исчерпании
+// MaxMessagesPerConn и после закрытия пула.
+func (p *SMTPPool) put(c *smtpConn, err error) {
+	c.sent++
+	c.lastUsed = time.Now()
+
+	if err != nil {
+		var protoErr *textproto.Error
+		if !errors.As(err, &protoErr) || c.client.Reset() != nil {
+			_ = c.client.Close()
+			return
+		}
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed || c.sent >= p.cfg.MaxMessagesPerConn {
+		p.quit(c)
+		return
+	}
+	select {
+	case p.idle <- c:
+	default:
+		p.quit(c)
+	}
+}
+
+func (p *SMTPPool) quit(c *smtpConn) {
+	_ = c.conn.SetDeadline(time.Now().Add(p.cfg.DialTimeout))
+	if err := c.client.Quit(); err != nil {
+		_ = c.client.Close()
+	}
+}
+
+// loginAuth — механизм AUTH LOGIN, которого нет в net/smtp (его до сих пор требуют
+// некоторые серверы, например Office 365). Как и PlainAuth, пароль передается только по TLS
+// или на localhost.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && a.host != "localhost" && a.host != "127.0.0.1" && a.host != "::1" {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected AUTH LOGIN challenge %q", fromServer)
+}
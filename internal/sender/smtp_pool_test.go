@@ -0,0 +1,387 @@
+package sender
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"delayedNotifier/internal/app"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTPServer — SMTP-сервер в памяти с STARTTLS (или TLS с первого байта) и AUTH PLAIN/LOGIN/CRAM-MD5.
+type fakeSMTPServer struct {
+	t           *testing.T
+	ln          net.Listener
+	tlsConfig   *tls.Config
+	implicitTLS bool
+	rejectRcpt  string
+	deferRcpt   string
+
+	mu          sync.Mutex
+	conns       []net.Conn
+	connections int
+	auths       []string
+	tlsSessions int
+	messages    []fakeMail
+}
+
+const (
+	fakeSMTPUser     = "notifier@example.com"
+	fakeSMTPPassword = "secret"
+)
+
+func newFakeSMTPServer(t *testing.T, implicitTLS bool) (*fakeSMTPServer, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fake smtp"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &fakeSMTPServer{
+		t:           t,
+		ln:          ln,
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}},
+		implicitTLS: implicitTLS,
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		_ = ln.Close()
+		s.dropConnections()
+	})
+	return s, roots
+}
+
+func (s *fakeSMTPServer) config(auth string, roots *x509.CertPool) SMTPConfig {
+	mode := SMTPStartTLS
+	if s.implicitTLS {
+		mode = SMTPImplicit
+	}
+	return SMTPConfig{
+		Host:      "127.0.0.1",
+		Port:      s.ln.Addr().(*net.TCPAddr).Port,
+		Username:  fakeSMTPUser,
+		Password:  fakeSMTPPassword,
+		TLS:       mode,
+		Auth:      auth,
+		TLSConfig: &tls.Config{RootCAs: roots},
+	}
+}
+
+func (s *fakeSMTPServer) stats() (connections int, auths []string, messages []fakeMail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections, append([]string(nil), s.auths...), append([]fakeMail(nil), s.messages...)
+}
+
+// dropConnections обрывает открытые соединения, как это делает сервер по таймауту простоя.
+func (s *fakeSMTPServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		_ = c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.connections++
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	secure := false
+	if s.implicitTLS {
+		conn = tls.Server(conn, s.tlsConfig)
+		secure = true
+	}
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...any) {
+		_ = tp.PrintfLine(format, args...)
+	}
+	readLine := func() string {
+		line, _ := tp.ReadLine()
+		return line
+	}
+
+	reply("220 fake ESMTP")
+	var mail fakeMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(cmd) {
+		case "EHLO", "HELO":
+			reply("250-fake")
+			if !secure {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN LOGIN CRAM-MD5")
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if tlsConn.Handshake() != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			tp = textproto.NewConn(conn)
+			s.mu.Lock()
+			s.tlsSessions++
+			s.mu.Unlock()
+		case "AUTH":
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			if s.authenticate(mechanism, initial, reply, readLine) {
+				s.mu.Lock()
+				s.auths = append(s.auths, mechanism)
+				s.mu.Unlock()
+				reply("235 authenticated")
+			} else {
+				reply("535 authentication failed")
+			}
+		case "MAIL":
+			mail = fakeMail{from: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			reply("250 ok")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if rcpt == s.rejectRcpt {
+				reply("550 no such user")
+				continue
+			}
+			if rcpt == s.deferRcpt {
+				reply("450 mailbox busy")
+				continue
+			}
+			mail.to = append(mail.to, rcpt)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			mail.data = string(data)
+			s.mu.Lock()
+			s.messages = append(s.messages, mail)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "RSET":
+			mail = fakeMail{}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unknown command")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) authenticate(mechanism, initial string, reply func(string, ...any), readLine func() string) bool {
+	decode := func(v string) string {
+		b, _ := base64.StdEncoding.DecodeString(v)
+		return string(b)
+	}
+	challenge := func(text string) string {
+		reply("334 %s", base64.StdEncoding.EncodeToString([]byte(text)))
+		return decode(readLine())
+	}
+	switch mechanism {
+	case "PLAIN":
+		return decode(initial) == "\x00"+fakeSMTPUser+"\x00"+fakeSMTPPassword
+	case "LOGIN":
+		return challenge("Username:") == fakeSMTPUser && challenge("Password:") == fakeSMTPPassword
+	case "CRAM-MD5":
+		nonce := "<1.2@fake>"
+		mac := hmac.New(md5.New, []byte(fakeSMTPPassword))
+		mac.Write([]byte(nonce))
+		return challenge(nonce) == fakeSMTPUser+" "+hex.EncodeToString(mac.Sum(nil))
+	}
+	return false
+}
+
+func TestSMTPPoolReusesConnection(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	pool, err := NewSMTPPool(srv.config(SMTPAuthLogin, roots))
+	assert.NoError(t, err)
+	defer func() {
+		_ = pool.Close()
+	}()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("Subject: hi\r\n\r\nbody "+strconv.Itoa(i)+"\r\n")))
+	}
+
+	connections, auths, messages := srv.stats()
+	assert.Equal(t, 1, connections)
+	assert.Equal(t, []string{"LOGIN"}, auths)
+	assert.Equal(t, 1, srv.tlsSessions) // соединение зашифровано через STARTTLS
+	assert.Len(t, messages, 3)
+	assert.Equal(t, fakeSMTPUser, messages[0].from)
+	assert.Equal(t, []string{"user@example.com"}, messages[0].to)
+	assert.Contains(t, messages[2].data, "body 2")
+}
+
+func TestEmailChannelUsesFromAsEnvelopeSender(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	cfg := srv.config(SMTPAuthNone, roots)
+	cfg.Username, cfg.Password = "", ""
+	pool, err := NewSMTPPool(cfg)
+	assert.NoError(t, err)
+	ch := &EmailChannel{pool: pool, from: mail.Address{Name: "Notifier", Address: "noreply@example.com"}}
+	defer func() {
+		_ = ch.Close()
+	}()
+
+	assert.NoError(t, ch.Send(&app.Notification{ID: uuid.New(), Recipient: "user@example.com", Message: "hi"}))
+
+	_, auths, messages := srv.stats()
+	assert.Empty(t, auths)
+	assert.Len(t, messages, 1)
+	// без логина SMTP конверт все равно берет адрес отправителя, а не пустой null sender
+	assert.Equal(t, "noreply@example.com", messages[0].from)
+}
+
+func TestSMTPPoolMaxMessagesPerConnection(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	cfg := srv.config(SMTPAuthCRAMMD5, roots)
+	cfg.MaxMessagesPerConn = 2
+	pool, err := NewSMTPPool(cfg)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\nhi\r\n")))
+	}
+
+	connections, auths, _ := srv.stats()
+	assert.Equal(t, 2, connections)
+	assert.Equal(t, []string{"CRAM-MD5", "CRAM-MD5"}, auths)
+}
+
+func TestSMTPPoolImplicitTLS(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, true)
+	pool, err := NewSMTPPool(srv.config(SMTPAuthPlain, roots))
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\nhi\r\n")))
+
+	_, auths, messages := srv.stats()
+	assert.Equal(t, []string{"PLAIN"}, auths)
+	assert.Len(t, messages, 1)
+}
+
+func TestSMTPPoolUntrustedCertificate(t *testing.T) {
+	srv, _ := newFakeSMTPServer(t, false)
+	pool, err := NewSMTPPool(srv.config(SMTPAuthPlain, x509.NewCertPool()))
+	assert.NoError(t, err)
+
+	assert.Error(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\nhi\r\n")))
+}
+
+func TestSMTPPoolHealthCheckRedialsDroppedConnection(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	cfg := srv.config(SMTPAuthPlain, roots)
+	cfg.HealthCheckInterval = time.Nanosecond
+	pool, err := NewSMTPPool(cfg)
+	assert.NoError(t, err)
+
+	assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\none\r\n")))
+	srv.dropConnections()
+	assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\ntwo\r\n")))
+
+	connections, _, messages := srv.stats()
+	assert.Equal(t, 2, connections)
+	assert.Len(t, messages, 2)
+}
+
+func TestSMTPPoolKeepsConnectionAfterRejectedRecipient(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	srv.rejectRcpt = "ghost@example.com"
+	pool, err := NewSMTPPool(srv.config(SMTPAuthPlain, roots))
+	assert.NoError(t, err)
+
+	err = pool.Send(fakeSMTPUser, []string{"ghost@example.com"}, []byte("\r\nhi\r\n"))
+	var protoErr *textproto.Error
+	assert.ErrorAs(t, err, &protoErr)
+	assert.Equal(t, 550, protoErr.Code)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+
+	assert.NoError(t, pool.Send(fakeSMTPUser, []string{"user@example.com"}, []byte("\r\nhi\r\n")))
+	connections, _, messages := srv.stats()
+	assert.Equal(t, 1, connections)
+	assert.Len(t, messages, 1)
+}
+
+func TestSMTPPoolTemporaryRejectionIsRetryable(t *testing.T) {
+	srv, roots := newFakeSMTPServer(t, false)
+	srv.deferRcpt = "busy@example.com"
+	pool, err := NewSMTPPool(srv.config(SMTPAuthPlain, roots))
+	assert.NoError(t, err)
+
+	err = pool.Send(fakeSMTPUser, []string{"busy@example.com"}, []byte("\r\nhi\r\n"))
+	var protoErr *textproto.Error
+	assert.ErrorAs(t, err, &protoErr)
+	assert.Equal(t, 450, protoErr.Code)
+	assert.False(t, IsPermanent(err))
+}
+
+func TestNewSMTPPoolRejectsUnknownModes(t *testing.T) {
+	_, err := NewSMTPPool(SMTPConfig{Host: "smtp.example.com", TLS: "ssl3"})
+	assert.Error(t, err)
+	_, err = NewSMTPPool(SMTPConfig{Host: "smtp.example.com", Auth: "ntlm"})
+	assert.Error(t, err)
+
+	pool, err := NewSMTPPool(SMTPConfig{Host: "smtp.example.com", Port: 465})
+	assert.NoError(t, err)
+	assert.Equal(t, SMTPImplicit, pool.cfg.TLS)
+}
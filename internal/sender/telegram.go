@@ -3,71 +3,221 @@ package sender
 import (
 	"delayedNotifier/internal/app"
 	"delayedNotifier/internal/config"
+	"errors"
 	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// telegramMaxWait — дольше отправка не ждет своей очереди внутри Send: все это время занят
+// воркер консьюмера, поэтому более дальний слот возвращается как RetryableError с RetryAfter.
+const telegramMaxWait = time.Second
+
 type TelegramChannel struct {
-	bot *tgbotapi.BotAPI
+	bot         *tgbotapi.BotAPI
+	subscribers TelegramSubscriberStorage
+	limiter     *telegramLimiter
+	maxWait     time.Duration
+}
+
+func init() {
+	Register(app.ChannelSpec{
+		Name:             app.Telegram,
+		MaxMessageLength: 4096,
+		ValidRecipient:   app.ValidTelegramRecipient,
+		ParseModes:       []string{tgbotapi.ModeMarkdownV2, tgbotapi.ModeHTML},
+		MaxButtons:       100,
+		SilentDelivery:   true,
+	}, func(cfg *config.AppConfig, repo StorageProvider) (Sender, error) {
+		return NewTelegramChannel(cfg, repo)
+	})
 }
 
-func NewTelegramChannel(cfg *config.AppConfig) *TelegramChannel {
+func NewTelegramChannel(cfg *config.AppConfig, subscribers TelegramSubscriberStorage) (*TelegramChannel, error) {
 	bot, err := tgbotapi.NewBotAPI(cfg.TelegramConfig.BotToken)
 	if err != nil {
 		wbzlog.Logger.Error().Err(err).Msg("Failed to create Telegram bot")
-		return nil
+		return nil, err
 	}
 
-	tc := &TelegramChannel{bot: bot}
-	go tc.listenForStartCommand()
-	return tc
+	tc := cfg.TelegramConfig
+	t := newTelegramChannel(bot, subscribers, newTelegramLimiter(tc.GlobalRate, tc.ChatInterval, tc.GroupInterval), tc.MaxWait)
+	go t.listenForUpdates()
+	return t, nil
+}
+
+func newTelegramChannel(bot *tgbotapi.BotAPI, subscribers TelegramSubscriberStorage, limiter *telegramLimiter, maxWait time.Duration) *TelegramChannel {
+	if maxWait <= 0 || maxWait > telegramMaxWait {
+		maxWait = telegramMaxWait
+	}
+	return &TelegramChannel{bot: bot, subscribers: subscribers, limiter: limiter, maxWait: maxWait}
 }
 
-// Send — реализация интерфейса Sender
+// Send — реализация интерфейса Sender. Получатель @username ищется среди подписчиков бота
+// в момент отправки. Отправка ждет своей очереди по ограничениям частоты Telegram
+// (не дольше maxWait, см. telegramMaxWait), ошибки API классифицируются в classifyTelegramError.
 func (t *TelegramChannel) Send(notification *app.Notification) error {
-	chatId, err := strconv.Atoi(notification.Recipient)
+	chatID, err := t.resolveChatID(notification.Recipient)
+	if err != nil {
+		return err
+	}
+
+	wait, ok := t.limiter.reserve(chatID, time.Now(), t.maxWait)
+	if !ok {
+		return &RetryableError{Err: errors.New("telegram rate limit for chat is exhausted"), RetryAfter: wait}
+	}
+	time.Sleep(wait)
+
+	if _, err := t.bot.Send(telegramMessage(chatID, notification)); err != nil {
+		err = t.classifyTelegramError(chatID, err)
+		if errors.Is(err, ErrRecipientUnreachable) {
+			t.unsubscribe(chatID)
+		}
+		return err
+	}
+	return nil
+}
+
+// resolveChatID переводит получателя в chat ID. Неизвестный @username — постоянная ошибка:
+// пользователь еще не запускал бота или отписался.
+func (t *TelegramChannel) resolveChatID(recipient string) (int64, error) {
+	if username, ok := app.ParseTelegramUsername(recipient); ok {
+		subscriber, err := t.subscribers.GetTelegramSubscriberByUsername(username)
+		if err != nil {
+			return 0, err
+		}
+		if subscriber == nil {
+			return 0, &PermanentError{Err: fmt.Errorf("%w: @%s has not started the bot", ErrRecipientUnreachable, username)}
+		}
+		return subscriber.ChatID, nil
+	}
+
+	chatID, err := strconv.ParseInt(recipient, 10, 64)
 	if err != nil {
-		return fmt.Errorf("invalid chat ID: %w", err)
+		return 0, &PermanentError{Err: fmt.Errorf("%w: invalid chat id %q", ErrRecipientUnreachable, recipient)}
+	}
+	return chatID, nil
+}
+
+func telegramMessage(chatID int64, notification *app.Notification) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, notification.Message)
+	msg.ParseMode = notification.ParseMode
+	msg.DisableNotification = notification.Silent
+	if len(notification.Buttons) > 0 {
+		rows := make([][]tgbotapi.InlineKeyboardButton, len(notification.Buttons))
+		for i, row := range notification.Buttons {
+			for _, b := range row {
+				rows[i] = append(rows[i], tgbotapi.NewInlineKeyboardButtonURL(b.Text, b.URL))
+			}
+		}
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	return msg
+}
+
+// classifyTelegramError делит ошибки Bot API на временные (429 — RetryableError с retry_after
+// от сервера), постоянные из-за получателя (бот заблокирован, чат не найден — ErrRecipientUnreachable)
+// и из-за содержимого (остальные 400 — ErrInvalidContent). Сетевые ошибки и 5xx возвращаются как есть
+// и повторяются по общей политике.
+func (t *TelegramChannel) classifyTelegramError(chatID int64, err error) error {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch {
+	case apiErr.Code == 429 || apiErr.RetryAfter > 0:
+		retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+		if retryAfter <= 0 {
+			retryAfter = time.Second
+		}
+		t.limiter.backoff(chatID, time.Now().Add(retryAfter))
+		return &RetryableError{Err: err, RetryAfter: retryAfter}
+	case apiErr.MigrateToChatID != 0:
+		return &PermanentError{Err: fmt.Errorf("%w: group was upgraded to supergroup %d", ErrRecipientUnreachable, apiErr.MigrateToChatID)}
+	case apiErr.Code == 403, apiErr.Code == 400 && chatIsMissing(apiErr.Message):
+		return &PermanentError{Err: fmt.Errorf("%w: %s", ErrRecipientUnreachable, apiErr.Message)}
+	case apiErr.Code == 400:
+		return &PermanentError{Err: fmt.Errorf("%w: %s", ErrInvalidContent, apiErr.Message)}
 	}
-	msg := tgbotapi.NewMessage(int64(chatId), notification.Message)
-	_, err = t.bot.Send(msg)
 	return err
 }
 
-func (t *TelegramChannel) listenForStartCommand() {
+// chatIsMissing распознает ответы 400 о несуществующем или недоступном чате.
+func chatIsMissing(description string) bool {
+	description = strings.ToLower(description)
+	for _, s := range []string{"chat not found", "user not found", "peer_id_invalid", "user is deactivated", "bot was kicked"} {
+		if strings.Contains(description, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// listenForUpdates ведет список подписчиков бота: /start сохраняет чат, /stop и блокировка
+// бота (или исключение из группы) удаляют его.
+func (t *TelegramChannel) listenForUpdates() {
 	log.Println("Telegram listener started...")
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
+	u.AllowedUpdates = []string{"message", "my_chat_member"}
 
-	updates := t.bot.GetUpdatesChan(u)
+	for update := range t.bot.GetUpdatesChan(u) {
+		t.handleUpdate(update)
+	}
+}
 
-	for update := range updates {
-		if update.Message == nil {
-			continue
+func (t *TelegramChannel) handleUpdate(update tgbotapi.Update) {
+	if member := update.MyChatMember; member != nil {
+		if status := member.NewChatMember.Status; status == "kicked" || status == "left" {
+			t.unsubscribe(member.Chat.ID)
 		}
+		return
+	}
+	if update.Message == nil {
+		return
+	}
 
-		if update.Message.Text == "/start" {
-			chatID := update.Message.Chat.ID
-			username := update.Message.From.UserName
-
-			// логируем и уведомляем пользователя
-			log.Printf("[TG] User %s started bot, chat_id=%d", username, chatID)
+	chat := update.Message.Chat
+	switch update.Message.Command() {
+	case "start":
+		name := chat.FirstName
+		if name == "" {
+			name = chat.Title
+		}
+		subscriber := app.NewTelegramSubscriber(chat.ID, chat.UserName, name)
+		if err := t.subscribers.SaveTelegramSubscriber(subscriber); err != nil {
+			wbzlog.Logger.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to save Telegram subscriber")
+			t.reply(chat.ID, "Не удалось оформить подписку, попробуйте позже.")
+			return
+		}
+		wbzlog.Logger.Info().Int64("chat_id", chat.ID).Str("username", chat.UserName).Msg("Telegram subscriber started bot")
 
-			msg := tgbotapi.NewMessage(chatID,
-				fmt.Sprintf("👋 Привет, %s!\n\nТвой chat_id: `%d`\nОтправь его в приложение, чтобы получать уведомления.",
-					username, chatID))
+		text := fmt.Sprintf("👋 Привет, %s!\n\nТвой chat_id: %d", name, chat.ID)
+		if chat.UserName != "" {
+			text += fmt.Sprintf("\nУведомления можно адресовать как @%s.", chat.UserName)
+		}
+		t.reply(chat.ID, text+"\nЧтобы отписаться, отправь /stop.")
+	case "stop":
+		t.unsubscribe(chat.ID)
+		t.reply(chat.ID, "Ты отписался от уведомлений. Чтобы подписаться снова, отправь /start.")
+	}
+}
 
-			if _, err := t.bot.Send(msg); err != nil {
-				wbzlog.Logger.Error().
-					Err(err).
-					Msg("Failed to send Telegram message")
-			}
+func (t *TelegramChannel) unsubscribe(chatID int64) {
+	if _, err := t.subscribers.DeleteTelegramSubscriber(chatID); err != nil {
+		wbzlog.Logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to delete Telegram subscriber")
+		return
+	}
+	wbzlog.Logger.Info().Int64("chat_id", chatID).Msg("Telegram subscriber removed")
+}
 
-			//TODO: сохранить chatID и username в БД или кэш
-			// _ = saveUserToDB(username, chatID)
-		}
+func (t *TelegramChannel) reply(chatID int64, text string) {
+	if _, err := t.bot.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		wbzlog.Logger.Error().Err(err).Msg("Failed to send Telegram message")
 	}
 }
@@ -0,0 +1,83 @@
+package sender
+
+import (
+	"sync"
+	"time"
+)
+
+// telegramLimiter распределяет отправки во времени с учетом общего предела бота и предела
+// на чат. Группы и каналы (отрицательный chat_id) ограничены строже личных чатов.
+type telegramLimiter struct {
+	mu             sync.Mutex
+	globalInterval time.Duration
+	chatInterval   time.Duration
+	groupInterval  time.Duration
+	nextGlobal     time.Time
+	nextChat       map[int64]time.Time
+}
+
+// maxTrackedChats — после стольких чатов из карты удаляются те, чье окно уже прошло.
+const maxTrackedChats = 10000
+
+func newTelegramLimiter(globalRate float64, chatInterval, groupInterval time.Duration) *telegramLimiter {
+	if globalRate <= 0 {
+		globalRate = 30
+	}
+	if chatInterval <= 0 {
+		chatInterval = time.Second
+	}
+	if groupInterval <= 0 {
+		groupInterval = 3 * time.Second
+	}
+	return &telegramLimiter{
+		globalInterval: time.Duration(float64(time.Second) / globalRate),
+		chatInterval:   chatInterval,
+		groupInterval:  groupInterval,
+		nextChat:       map[int64]time.Time{},
+	}
+}
+
+// reserve бронирует ближайший свободный слот для чата и возвращает, сколько до него ждать.
+// Если ждать пришлось бы дольше maxWait, слот не бронируется и возвращается false.
+func (l *telegramLimiter) reserve(chatID int64, now time.Time, maxWait time.Duration) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	at := now
+	if l.nextGlobal.After(at) {
+		at = l.nextGlobal
+	}
+	if next := l.nextChat[chatID]; next.After(at) {
+		at = next
+	}
+	wait := at.Sub(now)
+	if wait > maxWait {
+		return wait, false
+	}
+
+	interval := l.chatInterval
+	if chatID < 0 {
+		interval = l.groupInterval
+	}
+	l.nextGlobal = at.Add(l.globalInterval)
+	l.nextChat[chatID] = at.Add(interval)
+
+	if len(l.nextChat) > maxTrackedChats {
+		for id, next := range l.nextChat {
+			if next.Before(now) {
+				delete(l.nextChat, id)
+			}
+		}
+	}
+	return wait, true
+}
+
+// backoff запрещает отправку в чат до until (Telegram вернул 429 с retry_after).
+func (l *telegramLimiter) backoff(chatID int64, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until.After(l.nextChat[chatID]) {
+		l.nextChat[chatID] = until
+	}
+}
@@ -0,0 +1,252 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSubscriberStore — подписчики бота в памяти.
+type fakeSubscriberStore struct {
+	subscribers map[int64]*app.TelegramSubscriber
+}
+
+func (f *fakeSubscriberStore) SaveTelegramSubscriber(s *app.TelegramSubscriber) error {
+	f.subscribers[s.ChatID] = s
+	return nil
+}
+
+func (f *fakeSubscriberStore) GetTelegramSubscriberByUsername(username string) (*app.TelegramSubscriber, error) {
+	for _, s := range f.subscribers {
+		if strings.EqualFold(s.Username, username) {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSubscriberStore) DeleteTelegramSubscriber(chatID int64) (bool, error) {
+	_, ok := f.subscribers[chatID]
+	delete(f.subscribers, chatID)
+	return ok, nil
+}
+
+// newFakeTelegram поднимает Bot API, который отвечает на sendMessage ответом reply
+// и сохраняет параметры последнего запроса в sent.
+func newFakeTelegram(t *testing.T, reply string) (*TelegramChannel, *url.Values) {
+	sent := &url.Values{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/getMe") {
+			_, _ = w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"notifier","username":"notifier_bot"}}`))
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		*sent = r.PostForm
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithClient("TOKEN", srv.URL+"/bot%s/%s", srv.Client())
+	require.NoError(t, err)
+	store := &fakeSubscriberStore{subscribers: map[int64]*app.TelegramSubscriber{}}
+	return newTelegramChannel(bot, store, newTelegramLimiter(1000, time.Millisecond, time.Millisecond), time.Second), sent
+}
+
+const telegramOK = `{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":123,"type":"private"}}}`
+
+func TestTelegramSendMarkup(t *testing.T) {
+	tc, sent := newFakeTelegram(t, telegramOK)
+
+	n := &app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "123", Message: "<b>Заказ</b> отправлен",
+		ParseMode: tgbotapi.ModeHTML, Silent: true,
+		Buttons: [][]app.Button{{{Text: "Отследить", URL: "https://example.com/track/1"}, {Text: "Чат", URL: "tg://resolve?domain=support"}}},
+	}
+	assert.NoError(t, tc.Send(n))
+
+	assert.Equal(t, "123", sent.Get("chat_id"))
+	assert.Equal(t, "HTML", sent.Get("parse_mode"))
+	assert.Equal(t, "true", sent.Get("disable_notification"))
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	require.NoError(t, json.Unmarshal([]byte(sent.Get("reply_markup")), &markup))
+	require.Len(t, markup.InlineKeyboard, 1)
+	assert.Equal(t, "Отследить", markup.InlineKeyboard[0][0].Text)
+	assert.Equal(t, "tg://resolve?domain=support", *markup.InlineKeyboard[0][1].URL)
+}
+
+func TestTelegramSendPlain(t *testing.T) {
+	tc, sent := newFakeTelegram(t, telegramOK)
+
+	assert.NoError(t, tc.Send(&app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "123", Message: "Hi"}))
+	assert.Empty(t, sent.Get("parse_mode"))
+	assert.Empty(t, sent.Get("reply_markup"))
+	assert.Empty(t, sent.Get("disable_notification"))
+}
+
+func TestTelegramSendClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		reply     string
+		permanent error
+	}{
+		{"blocked", `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`, ErrRecipientUnreachable},
+		{"chat not found", `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`, ErrRecipientUnreachable},
+		{"migrated", `{"ok":false,"error_code":400,"description":"Bad Request: group chat was upgraded to a supergroup chat","parameters":{"migrate_to_chat_id":-1001}}`, ErrRecipientUnreachable},
+		{"bad markup", `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities: unsupported start tag"}`, ErrInvalidContent},
+		{"server error", `{"ok":false,"error_code":502,"description":"Bad Gateway"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, _ := newFakeTelegram(t, tt.reply)
+
+			err := tc.Send(&app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "123", Message: "Hi"})
+			assert.Error(t, err)
+			assert.Equal(t, tt.permanent != nil, IsPermanent(err))
+			if tt.permanent != nil {
+				assert.ErrorIs(t, err, tt.permanent)
+			}
+		})
+	}
+}
+
+func TestTelegramSendRateLimited(t *testing.T) {
+	tc, _ := newFakeTelegram(t, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`)
+
+	n := &app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "123", Message: "Hi"}
+	err := tc.Send(n)
+	var retryable *RetryableError
+	assert.True(t, errors.As(err, &retryable))
+	assert.Equal(t, 7*time.Second, retryable.RetryAfter)
+	assert.False(t, IsPermanent(err))
+
+	// пока действует retry_after, чат не дергается: ожидание больше maxWait
+	err = tc.Send(n)
+	assert.True(t, errors.As(err, &retryable))
+	assert.Greater(t, retryable.RetryAfter, 6*time.Second)
+}
+
+func TestTelegramSendDefersInsteadOfWaiting(t *testing.T) {
+	tc, _ := newFakeTelegram(t, telegramOK)
+	tc.limiter = newTelegramLimiter(1000, time.Minute, time.Minute)
+
+	n := &app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "123", Message: "Hi"}
+	assert.NoError(t, tc.Send(n))
+
+	start := time.Now()
+	err := tc.Send(n)
+	assert.Less(t, time.Since(start), telegramMaxWait)
+	var retryable *RetryableError
+	assert.True(t, errors.As(err, &retryable))
+	assert.Greater(t, retryable.RetryAfter, 59*time.Second)
+
+	// настроенное ожидание больше предела не позволяет держать воркер дольше telegramMaxWait
+	assert.Equal(t, telegramMaxWait, newTelegramChannel(nil, nil, tc.limiter, 10*time.Second).maxWait)
+}
+
+func TestTelegramSendToUsername(t *testing.T) {
+	tc, sent := newFakeTelegram(t, telegramOK)
+	assert.NoError(t, tc.subscribers.SaveTelegramSubscriber(app.NewTelegramSubscriber(555, "Alice_Smith", "Alice")))
+
+	assert.NoError(t, tc.Send(&app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "@alice_smith", Message: "Hi"}))
+	assert.Equal(t, "555", sent.Get("chat_id"))
+
+	err := tc.Send(&app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "@nobody_here", Message: "Hi"})
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+}
+
+func TestTelegramSendBlockedRemovesSubscriber(t *testing.T) {
+	tc, _ := newFakeTelegram(t, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)
+	assert.NoError(t, tc.subscribers.SaveTelegramSubscriber(app.NewTelegramSubscriber(555, "alice_smith", "Alice")))
+
+	err := tc.Send(&app.Notification{ID: uuid.New(), Channel: app.Telegram, Recipient: "@alice_smith", Message: "Hi"})
+	assert.True(t, IsPermanent(err))
+
+	s, _ := tc.subscribers.GetTelegramSubscriberByUsername("alice_smith")
+	assert.Nil(t, s)
+}
+
+func command(chat tgbotapi.Chat, text string) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		Chat:     &chat,
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(text)}},
+	}}
+}
+
+func TestTelegramStartAndStop(t *testing.T) {
+	tc, sent := newFakeTelegram(t, telegramOK)
+	chat := tgbotapi.Chat{ID: 555, Type: "private", UserName: "alice_smith", FirstName: "Alice"}
+
+	tc.handleUpdate(command(chat, "/start"))
+	s, _ := tc.subscribers.GetTelegramSubscriberByUsername("alice_smith")
+	assert.Equal(t, &app.TelegramSubscriber{ChatID: 555, Username: "alice_smith", FirstName: "Alice", SubscribedAt: s.SubscribedAt, UpdatedAt: s.UpdatedAt}, s)
+	assert.Equal(t, "555", sent.Get("chat_id"))
+	assert.Contains(t, sent.Get("text"), "@alice_smith")
+
+	tc.handleUpdate(command(chat, "/stop"))
+	s, _ = tc.subscribers.GetTelegramSubscriberByUsername("alice_smith")
+	assert.Nil(t, s)
+}
+
+func TestTelegramBlockedByUser(t *testing.T) {
+	tc, _ := newFakeTelegram(t, telegramOK)
+	chat := tgbotapi.Chat{ID: 555, Type: "private", UserName: "alice_smith"}
+	tc.handleUpdate(command(chat, "/start"))
+
+	tc.handleUpdate(tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          chat,
+		NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+	}})
+
+	s, _ := tc.subscribers.GetTelegramSubscriberByUsername("alice_smith")
+	assert.Nil(t, s)
+}
+
+func TestTelegramLimiter(t *testing.T) {
+	l := newTelegramLimiter(10, time.Second, 3*time.Second)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	wait, ok := l.reserve(1, now, time.Minute)
+	assert.True(t, ok)
+	assert.Zero(t, wait)
+
+	// другой чат ждет только общий интервал бота
+	wait, _ = l.reserve(2, now, time.Minute)
+	assert.Equal(t, 100*time.Millisecond, wait)
+
+	// тот же чат — интервал чата
+	wait, _ = l.reserve(1, now, time.Minute)
+	assert.Equal(t, time.Second, wait)
+
+	// слот дальше maxWait не бронируется
+	wait, ok = l.reserve(1, now, time.Second)
+	assert.False(t, ok)
+	assert.Equal(t, 2*time.Second, wait)
+	wait, _ = l.reserve(1, now, time.Minute)
+	assert.Equal(t, 2*time.Second, wait)
+
+	l.backoff(2, now.Add(time.Hour))
+	wait, ok = l.reserve(2, now, time.Minute)
+	assert.False(t, ok)
+	assert.Equal(t, time.Hour, wait)
+}
+
+func TestTelegramLimiterGroups(t *testing.T) {
+	l := newTelegramLimiter(30, time.Second, 3*time.Second)
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l.reserve(-100, now, time.Minute)
+	wait, _ := l.reserve(-100, now, time.Minute)
+	assert.Equal(t, 3*time.Second, wait)
+}
@@ -0,0 +1,219 @@
+package sender
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Webhook — доставка HTTP-запросом на URL получателя.
+const Webhook app.ChannelType = "webhook"
+
+// WebhookConfig — секция channels.webhook. SuccessStatuses — коды ответа, считающиеся доставкой:
+// точный код ("204"), класс ("2xx") или диапазон ("200-299"). AllowedHosts и DeniedHosts —
+// имена хостов ("hooks.example.com", "*.example.com"), IP или подсети CIDR; внутренние адреса
+// (loopback, link-local, частные сети) запрещены, пока они не перечислены в AllowedHosts
+// или не включен AllowPrivateNetworks.
+type WebhookConfig struct {
+	Method               string            `mapstructure:"method" default:"POST"`
+	Headers              map[string]string `mapstructure:"headers"`
+	Secret               string            `mapstructure:"secret" default:""`
+	SignatureHeader      string            `mapstructure:"signature_header" default:"X-Signature"`
+	Timeout              time.Duration     `mapstructure:"timeout" default:"10s"`
+	SuccessStatuses      []string          `mapstructure:"success_statuses" default:"2xx"`
+	AllowedHosts         []string          `mapstructure:"allowed_hosts"`
+	DeniedHosts          []string          `mapstructure:"denied_hosts"`
+	AllowPrivateNetworks bool              `mapstructure:"allow_private_networks" default:"false"`
+}
+
+// webhookPayload — тело запроса вебхука.
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Channel   string    `json:"channel"`
+	Recipient string    `json:"recipient"`
+	Message   string    `json:"message"`
+	SendAt    time.Time `json:"send_at"`
+}
+
+type statusRange struct {
+	from, to int
+}
+
+type WebhookChannel struct {
+	client          *http.Client
+	hosts           *hostPolicy
+	method          string
+	headers         map[string]string
+	secret          []byte
+	signatureHeader string
+	success         []statusRange
+}
+
+func init() {
+	Register(app.ChannelSpec{Name: Webhook, MaxMessageLength: 65536, ValidRecipient: defaultHostPolicy.validURL},
+		func(cfg *config.AppConfig, _ StorageProvider) (Sender, error) {
+			var wc WebhookConfig
+			if _, err := cfg.ChannelSection(string(Webhook), &wc); err != nil {
+				return nil, err
+			}
+			return NewWebhookChannel(wc)
+		})
+}
+
+func NewWebhookChannel(cfg WebhookConfig) (*WebhookChannel, error) {
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	if cfg.SignatureHeader == "" {
+		cfg.SignatureHeader = "X-Signature"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if len(cfg.SuccessStatuses) == 0 {
+		cfg.SuccessStatuses = []string{"2xx"}
+	}
+	if cfg.Secret == "" {
+		cfg.Secret = os.Getenv("WEBHOOK_SECRET")
+	}
+
+	hosts, err := newHostPolicy(cfg.AllowedHosts, cfg.DeniedHosts, cfg.AllowPrivateNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	success := make([]statusRange, 0, len(cfg.SuccessStatuses))
+	for _, rule := range cfg.SuccessStatuses {
+		r, err := parseStatusRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		success = append(success, r)
+	}
+
+	return &WebhookChannel{
+		client:          hosts.client(cfg.Timeout),
+		hosts:           hosts,
+		method:          strings.ToUpper(cfg.Method),
+		headers:         cfg.Headers,
+		secret:          []byte(cfg.Secret),
+		signatureHeader: cfg.SignatureHeader,
+		success:         success,
+	}, nil
+}
+
+// ValidRecipient проверяет URL получателя по политике хостов канала; реестр отдает ее API.
+func (w *WebhookChannel) ValidRecipient(recipient string) error {
+	return w.hosts.validURL(recipient)
+}
+
+// parseStatusRule разбирает правило успешного статуса: "204", "2xx" или "200-299".
+func parseStatusRule(rule string) (statusRange, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	if len(rule) == 3 && strings.HasSuffix(rule, "xx") && rule[0] >= '1' && rule[0] <= '5' {
+		class := int(rule[0]-'0') * 100
+		return statusRange{class, class + 99}, nil
+	}
+	if from, to, ok := strings.Cut(rule, "-"); ok {
+		f, err1 := strconv.Atoi(from)
+		t, err2 := strconv.Atoi(to)
+		if err1 == nil && err2 == nil && f <= t {
+			return statusRange{f, t}, nil
+		}
+	} else if code, err := strconv.Atoi(rule); err == nil {
+		return statusRange{code, code}, nil
+	}
+	return statusRange{}, fmt.Errorf("invalid webhook success status rule %q", rule)
+}
+
+func (w *WebhookChannel) isSuccess(status int) bool {
+	for _, r := range w.success {
+		if status >= r.from && status <= r.to {
+			return true
+		}
+	}
+	return false
+}
+
+// Send — реализация интерфейса Sender. Тело подписывается HMAC-SHA256 с секретом канала:
+// заголовок signature_header получает значение "sha256=<hex>". Запрещенный хост и ответы 4xx,
+// кроме 408 и 429, — постоянные ошибки: повтор их не исправит.
+func (w *WebhookChannel) Send(notification *app.Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:        notification.ID.String(),
+		Channel:   string(notification.Channel),
+		Recipient: notification.Recipient,
+		Message:   notification.Message,
+		SendAt:    notification.SendAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(w.method, notification.Recipient, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-ID", notification.ID.String())
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	if len(w.secret) > 0 {
+		req.Header.Set(w.signatureHeader, "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrHostNotAllowed) {
+			return &PermanentError{Err: fmt.Errorf("%w: %w", ErrRecipientUnreachable, err)}
+		}
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if !w.isSuccess(resp.StatusCode) {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+			return &RetryableError{Err: err, RetryAfter: retryAfter}
+		}
+		return classifyHTTPStatus(resp.StatusCode, err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// classifyHTTPStatus делает ошибки клиента постоянными: тело запроса (400) не изменится,
+// а пропавший или закрытый адрес (401, 403, 404, 410) сам не появится. 408 и 429 — временные.
+func classifyHTTPStatus(status int, err error) error {
+	switch {
+	case status == http.StatusRequestTimeout || status == http.StatusTooManyRequests:
+		return err
+	case status == http.StatusBadRequest:
+		return &PermanentError{Err: fmt.Errorf("%w: %v", ErrInvalidContent, err)}
+	case status >= 400 && status < 500:
+		return &PermanentError{Err: fmt.Errorf("%w: %v", ErrRecipientUnreachable, err)}
+	}
+	return err
+}
+
+// Sign возвращает HMAC-SHA256 тела в hex — так получатель вебхука проверяет подпись.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
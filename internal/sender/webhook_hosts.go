@@ -0,0 +1,181 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrHostNotAllowed — адрес вебхука запрещен политикой хостов канала.
+var ErrHostNotAllowed = errors.New("webhook host is not allowed")
+
+// hostPolicy решает, на какие хосты можно отправлять вебхуки. Без настроек запрещены loopback,
+// link-local, частные и прочие внутренние адреса: иначе любой клиент API мог бы заставить сервис
+// обращаться к внутренним системам (SSRF). Запрет проверяется и по хосту из URL, и по IP,
+// к которому действительно идет подключение, — так его не обойти DNS-записью на внутренний адрес.
+type hostPolicy struct {
+	allowPrivate bool
+	allowHosts   []string // имена хостов; "*.example.com" — любой поддомен
+	allowNets    []*net.IPNet
+	denyHosts    []string
+	denyNets     []*net.IPNet
+}
+
+// defaultHostPolicy — политика без настроек: внутренние адреса запрещены. По ней API проверяет
+// URL получателей, пока реестр не подставит проверку созданного из конфигурации отправителя.
+var defaultHostPolicy = &hostPolicy{}
+
+// maxRedirects — столько редиректов выполняет клиент с политикой хостов, как и http.Client по умолчанию.
+const maxRedirects = 10
+
+// newHostPolicy разбирает списки allowed_hosts и denied_hosts: имя хоста, "*.домен", IP или подсеть CIDR.
+func newHostPolicy(allowed, denied []string, allowPrivate bool) (*hostPolicy, error) {
+	p := &hostPolicy{allowPrivate: allowPrivate}
+	var err error
+	if p.allowHosts, p.allowNets, err = parseHostRules(allowed); err != nil {
+		return nil, err
+	}
+	if p.denyHosts, p.denyNets, err = parseHostRules(denied); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseHostRules(rules []string) ([]string, []*net.IPNet, error) {
+	var hosts []string
+	var nets []*net.IPNet
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		switch {
+		case rule == "":
+			continue
+		case strings.Contains(rule, "/"):
+			_, n, err := net.ParseCIDR(rule)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid webhook host rule %q: %w", rule, err)
+			}
+			nets = append(nets, n)
+		case net.ParseIP(rule) != nil:
+			ip := net.ParseIP(rule)
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			hosts = append(hosts, rule)
+		}
+	}
+	return hosts, nets, nil
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if p == host || (strings.HasPrefix(p, "*.") && strings.HasSuffix(host, p[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedByName проверяет хост из URL. true — хост явно разрешен и IP подключения не проверяется.
+func (p *hostPolicy) allowedByName(host string) (bool, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if matchHost(p.denyHosts, host) {
+		return false, fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+	}
+	if matchHost(p.allowHosts, host) {
+		return true, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return false, p.checkIP(ip)
+	}
+	if !p.allowPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost")) {
+		return false, fmt.Errorf("%w: %s is a loopback host", ErrHostNotAllowed, host)
+	}
+	return false, nil
+}
+
+// checkIP проверяет адрес, к которому идет подключение.
+func (p *hostPolicy) checkIP(ip net.IP) error {
+	if matchIP(p.denyNets, ip) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, ip)
+	}
+	if matchIP(p.allowNets, ip) || p.allowPrivate {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s is an internal address", ErrHostNotAllowed, ip)
+	}
+	return nil
+}
+
+// validURL проверяет, что получатель — абсолютный http(s) URL, хост которого не запрещен политикой.
+// Имя хоста здесь не разрешается в IP: адрес подключения проверяется при отправке.
+func (p *hostPolicy) validURL(recipient string) error {
+	u, err := url.Parse(recipient)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", recipient)
+	}
+	if _, err := p.allowedByName(u.Hostname()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// client возвращает HTTP-клиент, который подключается только к разрешенным политикой адресам
+// и не уходит редиректом на другую схему или запрещенный хост.
+func (p *hostPolicy) client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: p.dialContext(timeout)},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return p.validURL(req.URL.String())
+		},
+	}
+}
+
+// dialContext подключается к адресу из запроса, проверяя политику по имени хоста
+// и по каждому IP, в который оно разрешилось (в том числе после редиректов).
+func (p *hostPolicy) dialContext(timeout time.Duration) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		trusted, err := p.allowedByName(host)
+		if err != nil {
+			return nil, err
+		}
+		dialer := &net.Dialer{Timeout: timeout}
+		if !trusted {
+			dialer.Control = func(_, resolved string, _ syscall.RawConn) error {
+				ip, _, err := net.SplitHostPort(resolved)
+				if err != nil {
+					return err
+				}
+				return p.checkIP(net.ParseIP(ip))
+			}
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+}
@@ -0,0 +1,218 @@
+package sender
+
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loopback — httptest слушает 127.0.0.1, который политика хостов по умолчанию запрещает.
+var loopback = []string{"127.0.0.1"}
+
+func newWebhookNotification(url string) *app.Notification {
+	return &app.Notification{
+		ID:        uuid.New(),
+		Channel:   Webhook,
+		Recipient: url,
+		Message:   "deploy finished",
+		SendAt:    time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookSendSignsBody(t *testing.T) {
+	var (
+		method, signature, custom string
+		body                      []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		signature = r.Header.Get("X-Hub-Signature")
+		custom = r.Header.Get("X-Source")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhookChannel(WebhookConfig{
+		Method:          "put",
+		Headers:         map[string]string{"X-Source": "notifier"},
+		Secret:          "s3cret",
+		SignatureHeader: "X-Hub-Signature",
+		AllowedHosts:    loopback,
+	})
+	assert.NoError(t, err)
+
+	n := newWebhookNotification(srv.URL)
+	assert.NoError(t, w.Send(n))
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "notifier", custom)
+	assert.Equal(t, "sha256="+Sign([]byte("s3cret"), body), signature)
+
+	var payload webhookPayload
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, n.ID.String(), payload.ID)
+	assert.Equal(t, "deploy finished", payload.Message)
+}
+
+func TestWebhookSendSuccessStatusRules(t *testing.T) {
+	status := http.StatusAccepted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhookChannel(WebhookConfig{SuccessStatuses: []string{"200", "300-302"}, AllowedHosts: loopback})
+	assert.NoError(t, err)
+	n := newWebhookNotification(srv.URL)
+
+	err = w.Send(n)
+	assert.Error(t, err, "202 is not in the success rules")
+	assert.False(t, IsPermanent(err))
+
+	status = http.StatusFound
+	assert.NoError(t, w.Send(n))
+}
+
+func TestWebhookSendTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhookChannel(WebhookConfig{Timeout: 50 * time.Millisecond, AllowedHosts: loopback})
+	assert.NoError(t, err)
+
+	assert.Error(t, w.Send(newWebhookNotification(srv.URL)))
+}
+
+func TestWebhookSendClientErrorsArePermanent(t *testing.T) {
+	status := http.StatusNotFound
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhookChannel(WebhookConfig{AllowedHosts: loopback})
+	assert.NoError(t, err)
+	n := newWebhookNotification(srv.URL)
+
+	err = w.Send(n)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+
+	status = http.StatusBadRequest
+	assert.ErrorIs(t, w.Send(n), ErrInvalidContent)
+
+	for _, status = range []int{http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway} {
+		assert.False(t, IsPermanent(w.Send(n)), status)
+	}
+}
+
+func TestWebhookSendRejectsInternalHosts(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	w, err := NewWebhookChannel(WebhookConfig{})
+	assert.NoError(t, err)
+
+	err = w.Send(newWebhookNotification(srv.URL))
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+	assert.True(t, IsPermanent(err))
+	assert.False(t, called)
+
+	// имена loopback-хостов запрещены так же, как их адреса
+	err = w.Send(newWebhookNotification(strings.Replace(srv.URL, "127.0.0.1", "localhost.", 1)))
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+}
+
+func TestHostPolicy(t *testing.T) {
+	p, err := newHostPolicy([]string{"10.1.0.0/16", "*.corp.example"}, []string{"evil.example.com", "203.0.113.7"}, false)
+	assert.NoError(t, err)
+
+	for _, ip := range []string{"127.0.0.1", "::1", "169.254.169.254", "192.168.1.10", "10.2.0.1", "fd00::1", "0.0.0.0"} {
+		assert.ErrorIs(t, p.checkIP(net.ParseIP(ip)), ErrHostNotAllowed, ip)
+	}
+	for _, ip := range []string{"10.1.2.3", "93.184.216.34"} {
+		assert.NoError(t, p.checkIP(net.ParseIP(ip)), ip)
+	}
+	assert.ErrorIs(t, p.checkIP(net.ParseIP("203.0.113.7")), ErrHostNotAllowed)
+
+	trusted, err := p.allowedByName("hooks.corp.example")
+	assert.NoError(t, err)
+	assert.True(t, trusted)
+	_, err = p.allowedByName("evil.example.com")
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+	_, err = p.allowedByName("localhost")
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+	trusted, err = p.allowedByName("example.com")
+	assert.NoError(t, err)
+	assert.False(t, trusted)
+
+	_, err = newHostPolicy([]string{"10.0.0.0/33"}, nil, false)
+	assert.Error(t, err)
+}
+
+func TestParseStatusRule(t *testing.T) {
+	r, err := parseStatusRule("2xx")
+	assert.NoError(t, err)
+	assert.Equal(t, statusRange{200, 299}, r)
+
+	_, err = parseStatusRule("9xx")
+	assert.Error(t, err)
+	_, err = parseStatusRule("299-200")
+	assert.Error(t, err)
+}
+
+func TestWebhookRegistered(t *testing.T) {
+	spec, ok := app.LookupChannel(Webhook)
+
+	assert.True(t, ok)
+	assert.NoError(t, spec.ValidRecipient("https://example.com/hooks/1"))
+	assert.Error(t, spec.ValidRecipient("ftp://example.com"))
+	assert.Error(t, spec.ValidRecipient("/relative"))
+	assert.ErrorIs(t, spec.ValidRecipient("http://169.254.169.254/latest/meta-data"), ErrHostNotAllowed)
+	assert.ErrorIs(t, spec.ValidRecipient("http://localhost:8080/"), ErrHostNotAllowed)
+}
+
+func TestWebhookSendRejectsRedirectToInternalHost(t *testing.T) {
+	var called bool
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(internal.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer public.Close()
+
+	// разрешен только адрес первого сервера, редирект на localhost запрещен
+	w, err := NewWebhookChannel(WebhookConfig{AllowedHosts: loopback})
+	assert.NoError(t, err)
+
+	err = w.Send(newWebhookNotification(public.URL))
+	assert.ErrorIs(t, err, ErrHostNotAllowed)
+	assert.True(t, IsPermanent(err))
+	assert.False(t, called)
+}
+
+func TestWebhookValidRecipientUsesChannelPolicy(t *testing.T) {
+	w, err := NewWebhookChannel(WebhookConfig{AllowedHosts: loopback})
+	assert.NoError(t, err)
+	assert.NoError(t, w.ValidRecipient("http://127.0.0.1:8080/hook"))
+
+	// политика канала не меняет проверку по умолчанию
+	spec, _ := app.LookupChannel(Webhook)
+	assert.ErrorIs(t, spec.ValidRecipient("http://127.0.0.1:8080/hook"), ErrHostNotAllowed)
+}
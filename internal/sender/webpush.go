@@ -0,0 +1,394 @@
+package sender
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// WebPush — уведомление в браузер через Push API. Получатель — идентификатор, под которым
+// браузеры регистрируют подписки (POST /push/subscriptions); сообщение уходит во все его подписки.
+const WebPush app.ChannelType = "webpush"
+
+// webPushRecordSize — размер записи aes128gcm: сообщение шифруется одной записью,
+// а push-сервисы принимают тело не больше 4 КБ.
+const webPushRecordSize = 4096
+
+// webPushMaxPayload — предел содержимого записи: 1 байт занимает разделитель, 16 — тег AES-GCM.
+const webPushMaxPayload = webPushRecordSize - 1 - aes.BlockSize
+
+// errSubscriptionGone — push-сервис ответил 404/410: подписка больше не действует.
+var errSubscriptionGone = errors.New("push subscription is gone")
+
+// WebPushConfig — секция channels.webpush. Ключи VAPID — P-256 в base64url: закрытый — 32 байта,
+// открытый — 65 байт в несжатом виде (его же браузер получает как applicationServerKey).
+// Endpoint подписки присылает клиент, поэтому он проверяется той же политикой хостов, что и
+// вебхуки (AllowedHosts, DeniedHosts, AllowPrivateNetworks): внутренние адреса запрещены.
+type WebPushConfig struct {
+	VAPIDPublicKey  string        `mapstructure:"vapid_public_key" default:""`
+	VAPIDPrivateKey string        `mapstructure:"vapid_private_key" default:""`
+	Subject         string        `mapstructure:"subject" default:""` // mailto: или https: для связи с отправителем
+	Title           string        `mapstructure:"title" default:"DelayedNotifier"`
+	TTL             time.Duration `mapstructure:"ttl" default:"24h"`
+	Urgency         string        `mapstructure:"urgency" default:"normal"`
+	Timeout         time.Duration `mapstructure:"timeout" default:"10s"`
+
+	AllowedHosts         []string `mapstructure:"allowed_hosts"`
+	DeniedHosts          []string `mapstructure:"denied_hosts"`
+	AllowPrivateNetworks bool     `mapstructure:"allow_private_networks" default:"false"`
+}
+
+// webPushPayload — расшифрованное содержимое push-сообщения, которое получает service worker.
+type webPushPayload struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type WebPushChannel struct {
+	repo      PushSubscriptionStorage
+	client    *http.Client
+	hosts     *hostPolicy
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	title     string
+	ttl       time.Duration
+	urgency   string
+}
+
+func init() {
+	Register(app.ChannelSpec{Name: WebPush, MaxMessageLength: 1000, ValidRecipient: validSubscriber, ValidMessage: validWebPushMessage(defaultWebPushTitle)},
+		func(cfg *config.AppConfig, repo StorageProvider) (Sender, error) {
+			var wc WebPushConfig
+			if _, err := cfg.ChannelSection(string(WebPush), &wc); err != nil {
+				return nil, err
+			}
+			if wc.VAPIDPrivateKey == "" {
+				wc.VAPIDPrivateKey = os.Getenv("VAPID_PRIVATE_KEY")
+			}
+			if wc.VAPIDPrivateKey == "" {
+				return nil, ErrChannelDisabled
+			}
+			return NewWebPushChannel(wc, repo)
+		})
+}
+
+func validSubscriber(recipient string) error {
+	if recipient == "" || len(recipient) > 255 || strings.TrimSpace(recipient) != recipient {
+		return fmt.Errorf("%q is not a valid push subscriber id", recipient)
+	}
+	return nil
+}
+
+const defaultWebPushTitle = "DelayedNotifier"
+
+// validWebPushMessage проверяет, что сообщение с заголовком title после кодирования в JSON
+// (кавычки и не-ASCII символы занимают больше байт, чем символов) помещается в одну запись.
+func validWebPushMessage(title string) func(message string) error {
+	return func(message string) error {
+		payload, err := json.Marshal(webPushPayload{ID: uuid.Nil.String(), Title: title, Body: message})
+		if err != nil {
+			return err
+		}
+		if len(payload) > webPushMaxPayload {
+			return fmt.Errorf("push payload is %d bytes, at most %d fit into one record", len(payload), webPushMaxPayload)
+		}
+		return nil
+	}
+}
+
+func NewWebPushChannel(cfg WebPushConfig, repo PushSubscriptionStorage) (*WebPushChannel, error) {
+	raw, err := app.DecodeBase64URL(cfg.VAPIDPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("vapid_private_key: %w", err)
+	}
+	key, err := ecdsa.ParseRawPrivateKey(elliptic.P256(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("vapid_private_key: %w", err)
+	}
+	public, err := key.PublicKey.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	publicKey := base64.RawURLEncoding.EncodeToString(public)
+	if cfg.VAPIDPublicKey != "" && strings.TrimRight(cfg.VAPIDPublicKey, "=") != publicKey {
+		return nil, errors.New("vapid_public_key does not match vapid_private_key")
+	}
+
+	if cfg.Subject == "" {
+		cfg.Subject = "mailto:admin@localhost"
+	}
+	if cfg.Title == "" {
+		cfg.Title = defaultWebPushTitle
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.Urgency == "" {
+		cfg.Urgency = "normal"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	hosts, err := newHostPolicy(cfg.AllowedHosts, cfg.DeniedHosts, cfg.AllowPrivateNetworks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebPushChannel{
+		repo:      repo,
+		client:    hosts.client(cfg.Timeout),
+		hosts:     hosts,
+		key:       key,
+		publicKey: publicKey,
+		subject:   cfg.Subject,
+		title:     cfg.Title,
+		ttl:       cfg.TTL,
+		urgency:   cfg.Urgency,
+	}, nil
+}
+
+// ValidMessage проверяет размер содержимого push-сообщения с заголовком канала; реестр отдает
+// эту проверку API.
+func (w *WebPushChannel) ValidMessage(message string) error {
+	return validWebPushMessage(w.title)(message)
+}
+
+// ValidEndpoint проверяет endpoint подписки по политике хостов канала.
+func (w *WebPushChannel) ValidEndpoint(endpoint string) error {
+	return w.hosts.validURL(endpoint)
+}
+
+// Send — реализация интерфейса Sender. Сообщение отправляется во все подписки получателя;
+// подписки, которые push-сервис объявил недействительными (404/410), удаляются.
+// Отправка успешна, если сообщение доставлено хотя бы в одну подписку. Если действующих
+// подписок нет (или все запрещены политикой хостов), повтор не поможет: ошибка постоянная,
+// и уведомление переходит к запасному каналу.
+func (w *WebPushChannel) Send(notification *app.Notification) error {
+	subscriptions, err := w.repo.GetPushSubscriptions(notification.Recipient)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return &PermanentError{Err: fmt.Errorf("%w: recipient %q has no push subscriptions", ErrRecipientUnreachable, notification.Recipient)}
+	}
+
+	payload, err := json.Marshal(webPushPayload{
+		ID:    notification.ID.String(),
+		Title: w.title,
+		Body:  notification.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	delivered := 0
+	var errs []error
+	for _, s := range subscriptions {
+		err := w.push(s, payload)
+		switch {
+		case err == nil:
+			delivered++
+		case errors.Is(err, ErrHostNotAllowed):
+			wbzlog.Logger.Warn().Err(err).Str("subscription_id", s.ID.String()).Msg("Skipping push subscription with a disallowed endpoint")
+		case errors.Is(err, errSubscriptionGone):
+			wbzlog.Logger.Info().
+				Str("recipient", s.Recipient).
+				Str("subscription_id", s.ID.String()).
+				Msg("Pruning expired push subscription")
+			if _, err := w.repo.DeletePushSubscriptionByEndpoint(s.Endpoint); err != nil {
+				wbzlog.Logger.Error().Err(err).Str("subscription_id", s.ID.String()).Msg("Failed to delete expired push subscription")
+			}
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	if delivered > 0 {
+		return nil
+	}
+	if len(errs) == 0 {
+		return &PermanentError{Err: fmt.Errorf("%w: all push subscriptions of %q have expired or are not allowed", ErrRecipientUnreachable, notification.Recipient)}
+	}
+	return errors.Join(errs...)
+}
+
+// push шифрует payload для подписки и отправляет его в push-сервис.
+func (w *WebPushChannel) push(s *app.PushSubscription, payload []byte) error {
+	uaPublic, err := app.DecodeBase64URL(s.P256dh)
+	if err != nil {
+		return fmt.Errorf("subscription %s: p256dh: %w", s.ID, err)
+	}
+	authSecret, err := app.DecodeBase64URL(s.Auth)
+	if err != nil {
+		return fmt.Errorf("subscription %s: auth: %w", s.ID, err)
+	}
+	body, err := encryptWebPush(payload, uaPublic, authSecret, nil, nil)
+	if err != nil {
+		return fmt.Errorf("subscription %s: %w", s.ID, err)
+	}
+	authorization, err := w.vapidAuthorization(s.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(w.ttl.Seconds())))
+	req.Header.Set("Urgency", w.urgency)
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return errSubscriptionGone
+	}
+	err = fmt.Errorf("push service responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); retryAfter > 0 {
+		return &RetryableError{Err: err, RetryAfter: retryAfter}
+	}
+	return err
+}
+
+// vapidAuthorization возвращает заголовок Authorization по RFC 8292: JWT (ES256) с audience —
+// origin push-сервиса — и открытый ключ сервера.
+func (w *WebPushChannel) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": w.subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// подпись JWS ES256 — r и s по 32 байта подряд, а не DER
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + w.publicKey, nil
+}
+
+// encryptWebPush шифрует сообщение для подписки по RFC 8291 (кодирование aes128gcm из RFC 8188)
+// одной записью. salt и asKey в рабочем коде nil — тогда они генерируются; тесты передают
+// фиксированные значения из RFC.
+func encryptWebPush(plaintext, uaPublic, authSecret, salt []byte, asKey *ecdh.PrivateKey) ([]byte, error) {
+	if len(plaintext) > webPushMaxPayload {
+		return nil, fmt.Errorf("push payload of %d bytes does not fit into one record", len(plaintext))
+	}
+	if !utf8.Valid(plaintext) {
+		return nil, errors.New("push payload must be valid UTF-8")
+	}
+
+	curve := ecdh.P256()
+	uaKey, err := curve.NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("p256dh: %w", err)
+	}
+	if asKey == nil {
+		if asKey, err = curve.GenerateKey(rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+	if salt == nil {
+		salt = make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	shared, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append(append([]byte("WebPush: info\x00"), uaPublic...), asPublic...)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, string(keyInfo), 32)
+	if err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 — разделитель последней записи
+	record := append(append([]byte{}, plaintext...), 0x02)
+
+	// заголовок: salt (16) || rs (4) || idlen (1) || keyid = as_public (65)
+	out := make([]byte, 0, 16+4+1+len(asPublic)+len(record)+gcm.Overhead())
+	out = append(out, salt...)
+	out = binary.BigEndian.AppendUint32(out, webPushRecordSize)
+	out = append(out, byte(len(asPublic)))
+	out = append(out, asPublic...)
+	return gcm.Seal(out, nonce, record, nil), nil
+}
@@ -0,0 +1,248 @@
+package sender
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"delayedNotifier/internal/app"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func b64(s string) []byte {
+	b, err := app.DecodeBase64URL(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// fakePushStore — подписки в памяти.
+type fakePushStore struct {
+	subscriptions []*app.PushSubscription
+	deleted       []string
+}
+
+func (f *fakePushStore) GetPushSubscriptions(recipient string) ([]*app.PushSubscription, error) {
+	var result []*app.PushSubscription
+	for _, s := range f.subscriptions {
+		if s.Recipient == recipient {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePushStore) DeletePushSubscriptionByEndpoint(endpoint string) (bool, error) {
+	f.deleted = append(f.deleted, endpoint)
+	return true, nil
+}
+
+// browser — ключи подписки на стороне браузера; decrypt повторяет расшифровку по RFC 8291.
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	auth := make([]byte, 16)
+	_, _ = rand.Read(auth)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(recipient, endpoint string) *app.PushSubscription {
+	return &app.PushSubscription{
+		ID:        uuid.New(),
+		Recipient: recipient,
+		Endpoint:  endpoint,
+		P256dh:    base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:      base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	salt, idLen := body[:16], int(body[20])
+	asPublic, ciphertext := body[21:21+idLen], body[21+idLen:]
+
+	asKey, err := ecdh.P256().NewPublicKey(asPublic)
+	assert.NoError(t, err)
+	shared, err := b.key.ECDH(asKey)
+	assert.NoError(t, err)
+
+	keyInfo := "WebPush: info\x00" + string(b.key.PublicKey().Bytes()) + string(asPublic)
+	ikm, _ := hkdf.Key(sha256.New, shared, b.auth, keyInfo, 32)
+	cek, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: aes128gcm\x00", 16)
+	nonce, _ := hkdf.Key(sha256.New, ikm, salt, "Content-Encoding: nonce\x00", 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0x02), record[len(record)-1])
+	return record[:len(record)-1]
+}
+
+func newVAPIDConfig(t *testing.T) WebPushConfig {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	raw, err := key.Bytes()
+	assert.NoError(t, err)
+	return WebPushConfig{VAPIDPrivateKey: base64.RawURLEncoding.EncodeToString(raw), Subject: "mailto:ops@example.com", AllowedHosts: loopback}
+}
+
+// Пример из приложения A RFC 8291.
+func TestEncryptWebPushRFC8291Vector(t *testing.T) {
+	asKey, err := ecdh.P256().NewPrivateKey(b64("yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	assert.NoError(t, err)
+
+	body, err := encryptWebPush(
+		[]byte("When I grow up, I want to be a watermelon"),
+		b64("BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"),
+		b64("BTBZMqHH6r4Tts7J_aSIgg"),
+		b64("DGv6ra1nlYgDCS1FRnbzlw"),
+		asKey,
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN",
+		base64.RawURLEncoding.EncodeToString(body))
+}
+
+func TestWebPushSendEncryptsAndSignsWithVAPID(t *testing.T) {
+	cfg := newVAPIDConfig(t)
+	b := newBrowser(t)
+
+	var body []byte
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	store := &fakePushStore{subscriptions: []*app.PushSubscription{b.subscription("user-1", srv.URL+"/push/abc")}}
+	ch, err := NewWebPushChannel(cfg, store)
+	assert.NoError(t, err)
+
+	n := &app.Notification{ID: uuid.New(), Channel: WebPush, Recipient: "user-1", Message: "Время встречи"}
+	assert.NoError(t, ch.Send(n))
+
+	assert.Equal(t, "aes128gcm", headers.Get("Content-Encoding"))
+	assert.Equal(t, "86400", headers.Get("TTL"))
+
+	var payload webPushPayload
+	assert.NoError(t, json.Unmarshal(b.decrypt(t, body), &payload))
+	assert.Equal(t, n.ID.String(), payload.ID)
+	assert.Equal(t, "Время встречи", payload.Body)
+
+	// JWT подписан ключом VAPID и адресован origin push-сервиса
+	auth := strings.TrimPrefix(headers.Get("Authorization"), "vapid t=")
+	token, publicKey, _ := strings.Cut(auth, ", k=")
+	parts := strings.Split(token, ".")
+	assert.Len(t, parts, 3)
+
+	var claims map[string]any
+	assert.NoError(t, json.Unmarshal(b64(parts[1]), &claims))
+	assert.Equal(t, srv.URL, claims["aud"])
+	assert.Equal(t, "mailto:ops@example.com", claims["sub"])
+
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), b64(publicKey))
+	assert.NoError(t, err)
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	sig := b64(parts[2])
+	assert.True(t, ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])))
+}
+
+func TestWebPushSendPrunesExpiredSubscriptions(t *testing.T) {
+	gone := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer gone.Close()
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ok.Close()
+
+	store := &fakePushStore{subscriptions: []*app.PushSubscription{
+		newBrowser(t).subscription("user-1", gone.URL+"/old"),
+		newBrowser(t).subscription("user-1", ok.URL+"/new"),
+	}}
+	ch, err := NewWebPushChannel(newVAPIDConfig(t), store)
+	assert.NoError(t, err)
+	n := &app.Notification{ID: uuid.New(), Channel: WebPush, Recipient: "user-1", Message: "hi"}
+
+	assert.NoError(t, ch.Send(n))
+	assert.Equal(t, []string{gone.URL + "/old"}, store.deleted)
+
+	// если не осталось ни одной действующей подписки, отправка не удалась без повторов
+	store.subscriptions = store.subscriptions[:1]
+	err = ch.Send(n)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+
+	store.subscriptions = nil
+	err = ch.Send(n)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrRecipientUnreachable)
+}
+
+func TestWebPushSendSkipsInternalEndpoints(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	cfg := newVAPIDConfig(t)
+	cfg.AllowedHosts = nil
+	store := &fakePushStore{subscriptions: []*app.PushSubscription{newBrowser(t).subscription("user-1", srv.URL+"/push")}}
+	ch, err := NewWebPushChannel(cfg, store)
+	assert.NoError(t, err)
+
+	err = ch.Send(&app.Notification{ID: uuid.New(), Channel: WebPush, Recipient: "user-1", Message: "hi"})
+	assert.True(t, IsPermanent(err))
+	assert.False(t, called)
+	// подписку не удаляем: ее может разрешить allowed_hosts
+	assert.Empty(t, store.deleted)
+
+	assert.ErrorIs(t, ch.ValidEndpoint(srv.URL+"/push"), ErrHostNotAllowed)
+	assert.ErrorIs(t, (&SenderRegistry{}).ValidPushEndpoint("https://169.254.169.254/push"), ErrHostNotAllowed)
+	assert.NoError(t, (&SenderRegistry{}).ValidPushEndpoint("https://fcm.googleapis.com/fcm/send/abc"))
+}
+
+func TestWebPushMessageValidation(t *testing.T) {
+	ch, err := NewWebPushChannel(newVAPIDConfig(t), &fakePushStore{})
+	assert.NoError(t, err)
+
+	// 1000 символов кириллицы — около 2 КБ, помещаются в запись
+	assert.NoError(t, ch.ValidMessage(strings.Repeat("ж", 1000)))
+	// управляющие символы в JSON занимают по 6 байт: 1000 символов не помещаются в 4 КБ
+	assert.Error(t, ch.ValidMessage(strings.Repeat("\x01", 1000)))
+
+	_, err = app.NewNotification("webpush", strings.Repeat("😀", 1000), "user-1", "2099-01-01T00:00:00Z", "")
+	assert.ErrorIs(t, err, app.ErrMessageTooLong)
+}
+
+func TestNewWebPushChannelRejectsMismatchedKeys(t *testing.T) {
+	cfg := newVAPIDConfig(t)
+	cfg.VAPIDPublicKey = base64.RawURLEncoding.EncodeToString(newBrowser(t).key.PublicKey().Bytes())
+
+	_, err := NewWebPushChannel(cfg, &fakePushStore{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"strconv"
+)
+
+type ContactHandler struct {
+	repo ContactStorage
+}
+
+type ContactStorage interface {
+	SaveContact(contact *app.Contact) error
+	GetContacts(limit, offset int) ([]*app.Contact, error)
+	GetContact(id string) (*app.Contact, error)
+	UpdateContact(contact *app.Contact) (bool, error)
+	DeleteContact(id string) (bool, error)
+}
+
+func NewContactHandler(repo ContactStorage) *ContactHandler {
+	return &ContactHandler{repo: repo}
+}
+
+// Create Contact godoc
+// @Summary      Create Contact
+// @Description  Создает контакт: адреса в каналах (email, telegram_chat_id, phone), каналы в порядке предпочтения,
+// @Description  язык и часовой пояс. Уведомление с contact_id отправляется по этим предпочтениям
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        contact  body  web.ContactRequest  true  "Contact to create"
+// @Success      201  {object}  app.Contact  "Created contact"
+// @Failure      400  {object}  ErrorResponse  "Malformed JSON or missing required fields"
+// @Failure      422  {object}  ErrorResponse  "Field values failed validation"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /contacts [post]
+func (h *ContactHandler) CreateContact(ctx *wbgin.Context) {
+	var req ContactRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+
+	contact, err := app.NewContact(req.details())
+	if err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	if err := h.repo.SaveContact(contact); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, contact)
+}
+
+// List Contacts godoc
+// @Summary      List Contacts
+// @Description  Возвращает контакты, новые первыми
+// @Tags         contacts
+// @Produce      json
+// @Param        limit   query  int  false  "Page size (default 50, max 500)"
+// @Param        offset  query  int  false  "Offset"
+// @Success      200  {array}   app.Contact  "Contacts"
+// @Failure      400  {object}  ErrorResponse  "Invalid pagination"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /contacts [get]
+func (h *ContactHandler) ListContacts(ctx *wbgin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "limit is invalid"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "offset is invalid"})
+		return
+	}
+
+	contacts, err := h.repo.GetContacts(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, contacts)
+}
+
+// Get Contact godoc
+// @Summary      Get Contact
+// @Description  Возвращает контакт по ID
+// @Tags         contacts
+// @Produce      json
+// @Param        id   path   string  true  "Contact ID"
+// @Success      200  {object}  app.Contact  "Contact"
+// @Failure      400  {object}  ErrorResponse  "Invalid contact ID"
+// @Failure      404  {object}  ErrorResponse  "Contact not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /contacts/{id} [get]
+func (h *ContactHandler) GetContact(ctx *wbgin.Context) {
+	contact, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, contact)
+}
+
+// Update Contact godoc
+// @Summary      Update Contact
+// @Description  Заменяет данные контакта целиком. Уже созданные уведомления контакта при отправке
+// @Description  выберут канал по новым предпочтениям
+// @Tags         contacts
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string              true  "Contact ID"
+// @Param        contact  body  web.ContactRequest  true  "New contact data"
+// @Success      200  {object}  app.Contact  "Updated contact"
+// @Failure      400  {object}  ErrorResponse  "Invalid contact ID, malformed JSON or missing required fields"
+// @Failure      404  {object}  ErrorResponse  "Contact not found"
+// @Failure      422  {object}  ErrorResponse  "Field values failed validation"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /contacts/{id} [put]
+func (h *ContactHandler) UpdateContact(ctx *wbgin.Context) {
+	contact, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+
+	var req ContactRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	if err := contact.Update(req.details()); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+
+	updated, err := h.repo.UpdateContact(contact)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if !updated {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, contact)
+}
+
+// Delete Contact godoc
+// @Summary      Delete Contact
+// @Description  Удаляет контакт. Его неотправленные уведомления уйдут по адресу, выбранному при создании
+// @Tags         contacts
+// @Produce      json
+// @Param        id   path   string  true  "Contact ID"
+// @Success      204  {string}  string  "Contact deleted"
+// @Failure      400  {object}  ErrorResponse  "Invalid contact ID"
+// @Failure      404  {object}  ErrorResponse  "Contact not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /contacts/{id} [delete]
+func (h *ContactHandler) DeleteContact(ctx *wbgin.Context) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return
+	}
+
+	deleted, err := h.repo.DeleteContact(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *ContactHandler) lookup(ctx *wbgin.Context) (*app.Contact, bool) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return nil, false
+	}
+
+	contact, err := h.repo.GetContact(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return nil, false
+	}
+	if contact == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return nil, false
+	}
+	return contact, true
+}
@@ -0,0 +1,150 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	"errors"
+	wbgin "github.com/wb-go/wbf/ginext"
+	wbzlog "github.com/wb-go/wbf/zlog"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+type DeadLetterHandler struct {
+	repo  DeadLetterStorage
+	cache CacheProvider
+}
+
+type DeadLetterStorage interface {
+	GetDeadLetters(limit, offset int) ([]*app.DeadLetter, error)
+	GetDeadLetter(id string) (*app.DeadLetter, error)
+	ReplayDeadLetter(dl *app.DeadLetter, notification *app.Notification) error
+}
+
+func NewDeadLetterHandler(repo DeadLetterStorage, cache CacheProvider) *DeadLetterHandler {
+	return &DeadLetterHandler{repo: repo, cache: cache}
+}
+
+// List Dead Letters godoc
+// @Summary      List Dead Letters
+// @Description  Возвращает сообщения из dead-letter очереди (битые и не доставленные после всех попыток)
+// @Tags         dead-letters
+// @Produce      json
+// @Param        limit   query  int  false  "Page size (default 50, max 500)"
+// @Param        offset  query  int  false  "Offset"
+// @Success      200  {array}   app.DeadLetter  "Dead letters"
+// @Failure      400  {object}  ErrorResponse  "Invalid pagination"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /dead-letters [get]
+func (h *DeadLetterHandler) ListDeadLetters(ctx *wbgin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "limit is invalid"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "offset is invalid"})
+		return
+	}
+
+	deadLetters, err := h.repo.GetDeadLetters(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, deadLetters)
+}
+
+// Get Dead Letter godoc
+// @Summary      Get Dead Letter
+// @Description  Возвращает сообщение из dead-letter очереди по ID
+// @Tags         dead-letters
+// @Produce      json
+// @Param        id   path   string  true  "Dead letter ID"
+// @Success      200  {object}  app.DeadLetter  "Dead letter"
+// @Failure      400  {object}  ErrorResponse  "Invalid dead letter ID"
+// @Failure      404  {object}  ErrorResponse  "Dead letter not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /dead-letters/{id} [get]
+func (h *DeadLetterHandler) GetDeadLetter(ctx *wbgin.Context) {
+	dl, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, dl)
+}
+
+// Replay Dead Letter godoc
+// @Summary      Replay Dead Letter
+// @Description  Возвращает уведомление из dead-letter очереди в основной поток: статус pending, счетчик попыток сброшен
+// @Tags         dead-letters
+// @Produce      json
+// @Param        id   path   string  true  "Dead letter ID"
+// @Success      202  {object}  app.Notification  "Notification scheduled for delivery"
+// @Failure      400  {object}  ErrorResponse  "Invalid dead letter ID"
+// @Failure      404  {object}  ErrorResponse  "Dead letter not found"
+// @Failure      409  {object}  ErrorResponse  "Dead letter already replayed or notification is not failed"
+// @Failure      422  {object}  ErrorResponse  "Malformed message cannot be replayed"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /dead-letters/{id}/replay [post]
+func (h *DeadLetterHandler) ReplayDeadLetter(ctx *wbgin.Context) {
+	dl, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	if dl.ReplayedAt != nil {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": "dead letter already replayed"})
+		return
+	}
+	if dl.NotificationID == nil {
+		ctx.JSON(http.StatusUnprocessableEntity, wbgin.H{"error": "malformed message cannot be replayed"})
+		return
+	}
+
+	notification, err := dl.Notification()
+	if err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, wbgin.H{"error": err.Error()})
+		return
+	}
+	notification.PrepareForReplay()
+
+	if err := h.repo.ReplayDeadLetter(dl, notification); err != nil {
+		if errors.Is(err, app.ErrStatusConflict) {
+			ctx.JSON(http.StatusConflict, wbgin.H{"error": "notification is not in failed status"})
+			return
+		}
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err := h.cache.SaveNotification(notification); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notification.ID.String()).
+			Msg("Failed to save replayed notification to cache")
+	}
+	ctx.JSON(http.StatusAccepted, notification)
+}
+
+func (h *DeadLetterHandler) lookup(ctx *wbgin.Context) (*app.DeadLetter, bool) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return nil, false
+	}
+
+	dl, err := h.repo.GetDeadLetter(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return nil, false
+	}
+	if dl == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return nil, false
+	}
+	return dl, true
+}
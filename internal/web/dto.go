@@ -1,8 +1,250 @@
 package web
 
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// NotificationRequest — новое уведомление. send_at — RFC3339 со смещением или, если задан timezone
+// (IANA, например Europe/Moscow), локальное время в этом поясе без смещения: 2030-03-01T09:00:00.
+// Здесь проверяется только наличие полей; значения по правилам канала проверяет app.NewNotification.
+// Вместо channel и recipient можно передать contact_id: канал выберется по предпочтениям контакта.
+// Fallbacks — запасные каналы по порядку: следующий используется, когда предыдущий исчерпал попытки.
 type NotificationRequest struct {
-	Channel   string `json:"channel" binding:"required,oneof=email telegram"`
+	Channel   string `json:"channel" binding:"required_without=ContactID,excluded_with=ContactID"`
 	Message   string `json:"message" binding:"required"`
+	Recipient string `json:"recipient" binding:"required_without=ContactID,excluded_with=ContactID"`
+	ContactID string `json:"contact_id,omitempty" binding:"omitempty,uuid"`
+	SendAt    string `json:"send_at" binding:"required"`
+	Timezone  string `json:"timezone,omitempty"`
+	// Subject, HTML и Attachments поддерживает только email; message остается текстовой версией письма
+	Subject     string           `json:"subject,omitempty"`
+	HTML        string           `json:"html,omitempty"`
+	Attachments []app.Attachment `json:"attachments,omitempty"`
+	// ParseMode (MarkdownV2, HTML), Buttons (ряды кнопок-ссылок) и Silent поддерживает telegram
+	ParseMode string         `json:"parse_mode,omitempty"`
+	Buttons   [][]app.Button `json:"buttons,omitempty"`
+	Silent    bool           `json:"silent,omitempty"`
+	// у уведомления контакта запасными становятся остальные его каналы
+	Fallbacks []app.Destination `json:"fallbacks,omitempty" binding:"excluded_with=ContactID"`
+	// IdempotencyKey — альтернатива заголовку Idempotency-Key
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// newNotification создает уведомление; contact — контакт из contact_id или nil.
+func (r NotificationRequest) newNotification(contact *app.Contact) (*app.Notification, error) {
+	if contact != nil {
+		return app.NewContactNotification(contact, r.Message, r.SendAt, r.Timezone, r.richContent())
+	}
+	n, err := app.NewRichNotification(r.Channel, r.Message, r.Recipient, r.SendAt, r.Timezone, r.richContent())
+	if err != nil {
+		return nil, err
+	}
+	if err := n.SetFallbacks(r.Fallbacks); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (r NotificationRequest) richContent() app.RichContent {
+	return app.RichContent{
+		Subject:     r.Subject,
+		HTML:        r.HTML,
+		Attachments: r.Attachments,
+		ParseMode:   r.ParseMode,
+		Buttons:     r.Buttons,
+		Silent:      r.Silent,
+	}
+}
+
+// hash — отпечаток запроса для ключа идемпотентности. Необязательные части (app.RichContent,
+// contact_id, fallbacks) входят в него, только если заданы: отпечатки запросов без них совпадают с сохраненными
+// до их появления.
+func (r NotificationRequest) hash() (string, error) {
+	fields := []string{r.Channel, r.Message, r.Recipient, r.SendAt, r.Timezone}
+	if r.ContactID != "" {
+		fields = append(fields, "contact:"+r.ContactID)
+	}
+	if content := r.richContent(); !content.IsZero() {
+		b, err := json.Marshal(content)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, string(b))
+	}
+	if len(r.Fallbacks) > 0 {
+		b, err := json.Marshal(r.Fallbacks)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, "fallbacks:"+string(b))
+	}
+	return app.HashRequest(fields...), nil
+}
+
+// NotificationUpdateRequest — изменения ожидающего уведомления. Отсутствующие поля не меняются.
+// Ожидаемая версия передается заголовком If-Match или полем version.
+type NotificationUpdateRequest struct {
+	Message   *string `json:"message"`
+	Recipient *string `json:"recipient"`
+	SendAt    *string `json:"send_at"`
+	Timezone  string  `json:"timezone,omitempty"`
+	Version   *int    `json:"version"`
+}
+
+// NotificationListRequest — параметры выборки GET /notify. Границы диапазонов в RFC3339,
+// status может содержать несколько значений через запятую.
+type NotificationListRequest struct {
+	Status      string `form:"status"`
+	Channel     string `form:"channel" binding:"omitempty,channel"`
+	Recipient   string `form:"recipient"`
+	SendFrom    string `form:"send_from" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	SendTo      string `form:"send_to" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedFrom string `form:"created_from" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `form:"created_to" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Query       string `form:"q"`
+	Sort        string `form:"sort"`
+	Order       string `form:"order"`
+	Limit       int    `form:"limit" binding:"omitempty,min=1,max=500"`
+	Cursor      string `form:"cursor"`
+}
+
+func (r NotificationListRequest) toQuery() (app.NotificationQuery, error) {
+	q := app.NotificationQuery{
+		Filter: app.NotificationFilter{
+			Channel:   app.ChannelType(r.Channel),
+			Recipient: r.Recipient,
+			Query:     r.Query,
+		},
+		Limit: r.Limit,
+	}
+	if q.Limit == 0 {
+		q.Limit = defaultPageSize
+	}
+
+	if r.Status != "" {
+		for _, s := range strings.Split(r.Status, ",") {
+			status := app.StatusType(strings.TrimSpace(s))
+			if !status.IsValid() {
+				return q, fmt.Errorf("unknown status %q", s)
+			}
+			q.Filter.Statuses = append(q.Filter.Statuses, status)
+		}
+	}
+
+	var err error
+	for _, bound := range []struct {
+		value string
+		dst   **time.Time
+	}{
+		{r.SendFrom, &q.Filter.SendFrom},
+		{r.SendTo, &q.Filter.SendTo},
+		{r.CreatedFrom, &q.Filter.CreatedFrom},
+		{r.CreatedTo, &q.Filter.CreatedTo},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return q, err
+		}
+		*bound.dst = &t
+	}
+
+	if q.Sort, err = app.ParseSortField(r.Sort); err != nil {
+		return q, err
+	}
+	if q.Order, err = app.ParseSortOrder(r.Order); err != nil {
+		return q, err
+	}
+	if r.Cursor != "" {
+		if q.After, err = app.DecodeCursor(r.Cursor, q.Sort); err != nil {
+			return q, err
+		}
+	}
+	return q, nil
+}
+
+// BatchItemResult — результат создания одного элемента пакета; Index — позиция в запросе.
+type BatchItemResult struct {
+	Index  int              `json:"index"`
+	ID     *uuid.UUID       `json:"id,omitempty"`
+	Error  string           `json:"error,omitempty"`
+	Fields []app.FieldError `json:"fields,omitempty"`
+}
+
+// BatchResponse — ответ POST /notify/batch: сколько создано, сколько отклонено и результат по каждому элементу.
+type BatchResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []BatchItemResult `json:"results"`
+}
+
+// ScheduleRequest — повторяющееся уведомление. expression — cron-выражение или RRULE в зависимости от kind,
+// срабатывания считаются в часовом поясе timezone (IANA, по умолчанию UTC).
+type ScheduleRequest struct {
+	Channel        string  `json:"channel" binding:"required,channel"`
+	Message        string  `json:"message" binding:"required"`
+	Recipient      string  `json:"recipient" binding:"required"`
+	Kind           string  `json:"kind" binding:"required,oneof=cron rrule"`
+	Expression     string  `json:"expression" binding:"required"`
+	Timezone       string  `json:"timezone"`
+	StartAt        string  `json:"start_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	EndAt          *string `json:"end_at" binding:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	MaxOccurrences int     `json:"max_occurrences" binding:"min=0"`
+}
+
+// QuietHoursRequest — тихие часы получателя: с start до end (ЧЧ:ММ) в поясе timezone уведомления не отправляются.
+// Пустой channel применяет окно ко всем каналам.
+type QuietHoursRequest struct {
+	Recipient string `json:"recipient" binding:"required"`
+	Channel   string `json:"channel" binding:"omitempty,channel"`
+	Timezone  string `json:"timezone"`
+	Start     string `json:"start" binding:"required"`
+	End       string `json:"end" binding:"required"`
+}
+
+// PushSubscriptionRequest — PushSubscription из браузера (результат pushSubscription.toJSON())
+// и получатель, к которому она привязывается.
+type PushSubscriptionRequest struct {
 	Recipient string `json:"recipient" binding:"required"`
-	SendAt    string `json:"send_at" binding:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	Endpoint  string `json:"endpoint" binding:"required"`
+	Keys      struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys"`
+}
+
+// ContactRequest — данные контакта. Адреса проверяются по правилам своих каналов, channels —
+// подмножество email, telegram, sms в порядке предпочтения (по умолчанию все каналы с адресом).
+type ContactRequest struct {
+	Name           string            `json:"name" binding:"required"`
+	Email          string            `json:"email,omitempty"`
+	TelegramChatID string            `json:"telegram_chat_id,omitempty"`
+	Phone          string            `json:"phone,omitempty"`
+	Channels       []app.ChannelType `json:"channels,omitempty"`
+	Locale         string            `json:"locale,omitempty"`
+	Timezone       string            `json:"timezone,omitempty"`
+}
+
+func (r ContactRequest) details() app.ContactDetails {
+	return app.ContactDetails{
+		Name:           r.Name,
+		Email:          r.Email,
+		TelegramChatID: r.TelegramChatID,
+		Phone:          r.Phone,
+		Channels:       r.Channels,
+		Locale:         r.Locale,
+		Timezone:       r.Timezone,
+	}
+}
+
+// VAPIDPublicKeyResponse — ключ для applicationServerKey в pushManager.subscribe().
+type VAPIDPublicKeyResponse struct {
+	PublicKey string `json:"public_key"`
 }
@@ -0,0 +1,100 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		// в ошибках валидатора поля называются так же, как в JSON, а не как в Go-структуре
+		v.RegisterTagNameFunc(jsonFieldName)
+		// binding:"channel" — канал зарегистрирован в app (см. sender.Register)
+		_ = v.RegisterValidation("channel", func(fl validator.FieldLevel) bool {
+			_, ok := app.LookupChannel(app.ChannelType(fl.Field().String()))
+			return ok
+		})
+	}
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// newErrorResponse строит тело ошибки и, где возможно, раскладывает ее по полям запроса:
+// ошибки binding-тегов, несовпадение типа в JSON и app.ValidationErrors.
+func newErrorResponse(err error) ErrorResponse {
+	resp := ErrorResponse{Error: err.Error()}
+
+	var appErrs app.ValidationErrors
+	var bindErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &appErrs):
+		resp.Error = "validation failed"
+		resp.Fields = appErrs
+	case errors.As(err, &bindErrs):
+		resp.Error = "validation failed"
+		for _, fe := range bindErrs {
+			resp.Fields = append(resp.Fields, app.FieldError{
+				Field:   fe.Field(),
+				Code:    fe.Tag(),
+				Message: bindingMessage(fe),
+			})
+		}
+	case errors.As(err, &typeErr):
+		resp.Fields = []app.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: "expected " + typeErr.Type.String() + ", got " + typeErr.Value,
+		}}
+	}
+	return resp
+}
+
+func bindingMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fe.Field() + " is required"
+	case "channel":
+		return fmt.Sprintf("unknown channel %q", fe.Value())
+	case "required_without":
+		return fe.Field() + " is required unless contact_id is set"
+	case "excluded_with":
+		return fe.Field() + " must not be set together with contact_id"
+	}
+	if fe.Param() != "" {
+		return fe.Field() + " must satisfy " + fe.Tag() + "=" + fe.Param()
+	}
+	return fe.Field() + " must satisfy " + fe.Tag()
+}
+
+// respondInvalid отвечает на ошибку входных данных: 422, если запрос разобран, но значения
+// не прошли проверку app (app.ValidationErrors), иначе 400.
+func respondInvalid(ctx *wbgin.Context, err error) {
+	status := http.StatusBadRequest
+	if errors.As(err, new(app.ValidationErrors)) {
+		status = http.StatusUnprocessableEntity
+	}
+	ctx.JSON(status, newErrorResponse(err))
+}
+
+// reject помечает элемент пакета отклоненным с той же раскладкой ошибки по полям, что и у одиночного запроса.
+func (r *BatchItemResult) reject(err error) {
+	resp := newErrorResponse(err)
+	r.Error = resp.Error
+	r.Fields = resp.Fields
+}
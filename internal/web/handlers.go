@@ -1,21 +1,44 @@
 package web
 
 import (
+	"context"
 	"delayedNotifier/internal/app"
+	"delayedNotifier/internal/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin/binding"
 	wbgin "github.com/wb-go/wbf/ginext"
 	wbzlog "github.com/wb-go/wbf/zlog"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// maxBatchSize — предельное число уведомлений в одном запросе POST /notify/batch.
+const maxBatchSize = 10000
+
 type NotifyHandler struct {
-	repo  StorageProvider
-	cache CacheProvider
+	repo        StorageProvider
+	cache       CacheProvider
+	idempotency config.Idempotency
 }
 
 type StorageProvider interface {
 	SaveNotification(notification *app.Notification) error
+	SaveNotifications(notifications []*app.Notification) error
+	SaveNotificationIdempotent(notification *app.Notification, key *app.IdempotencyKey) (*app.Notification, bool, error)
+	GetIdempotentNotification(key, requestHash string) (*app.Notification, error)
+	PurgeExpiredIdempotencyKeys() (int64, error)
 	GetNotification(id string) (*app.Notification, error)
+	ListNotifications(query app.NotificationQuery) ([]*app.Notification, error)
+	TransitionNotification(id string, to app.StatusType, from ...app.StatusType) error
+	UpdateNotification(notification *app.Notification, expectedVersion int) error
 	DeleteNotification(id string) error
+	GetContact(id string) (*app.Contact, error)
+	GetDeliveryAttempts(notificationID string) ([]*app.DeliveryAttempt, error)
 }
 
 type CacheProvider interface {
@@ -24,61 +47,335 @@ type CacheProvider interface {
 	DeleteNotification(id string) error
 }
 
-func NewNotifyHandler(repo StorageProvider, cache CacheProvider) *NotifyHandler {
-	return &NotifyHandler{repo: repo, cache: cache}
+func NewNotifyHandler(repo StorageProvider, cache CacheProvider, cfg *config.AppConfig) *NotifyHandler {
+	return &NotifyHandler{repo: repo, cache: cache, idempotency: idempotencyConfig(cfg.Idempotency)}
+}
+
+func idempotencyConfig(cfg config.Idempotency) config.Idempotency {
+	if cfg.Retention <= 0 {
+		cfg.Retention = 24 * time.Hour
+	}
+	if cfg.PurgeInterval <= 0 {
+		cfg.PurgeInterval = time.Hour
+	}
+	return cfg
 }
 
-// ErrorResponse представляет стандартную ошибку API
+// ErrorResponse представляет стандартную ошибку API. Fields перечисляет ошибки по полям запроса,
+// если их удалось определить.
 type ErrorResponse struct {
-	Error string `json:"error" example:"invalid input data"`
+	Error  string           `json:"error" example:"invalid input data"`
+	Fields []app.FieldError `json:"fields,omitempty"`
 }
 
 // Create Notification godoc
 // @Summary      Create Notification
-// @Description  Создает новое уведомление (Email, Telegram) и сохраняет его в БД и Redis
+// @Description  Создает новое уведомление (email, telegram, sms, webhook, slack, webpush) и сохраняет его в БД и Redis.
+// @Description  С ключом Idempotency-Key (заголовок или поле idempotency_key) повтор того же запроса
+// @Description  в течение срока хранения ключа возвращает исходное уведомление вместо создания нового.
+// @Description  С полем timezone send_at можно передать локальным временем в этом поясе (2030-03-01T09:00:00)
+// @Description  Для email можно задать subject, html (HTML-версия письма) и attachments (файлы в base64)
+// @Description  Для telegram можно задать parse_mode (MarkdownV2, HTML), buttons (inline-кнопки со ссылками) и silent
+// @Description  Вместо channel и recipient можно передать contact_id — канал выберется по предпочтениям контакта при отправке
+// @Description  Значения проверяются по правилам канала; ошибки возвращаются списком fields с именем поля и кодом
 // @Tags         notifications
 // @Accept       json
 // @Produce      json
-// @Param        notification  body  app.NotificationRequest  true  "Notification to create"
-// @Success      201  {object}  app.Notification  "Created notification"
-// @Failure      400  {object}  ErrorResponse  "Invalid input data"
+// @Param        notification     body    web.NotificationRequest  true   "Notification to create"
+// @Param        Idempotency-Key  header  string                   false  "Client-generated key for safe retries"
+// @Success      201  {object}  app.Notification  "Created notification (or the original one on replay, with Idempotent-Replayed: true)"
+// @Failure      400  {object}  ErrorResponse  "Malformed JSON or missing required fields"
+// @Failure      422  {object}  ErrorResponse  "Field values failed validation or idempotency key reused with a different request"
 // @Failure      503  {object}  ErrorResponse  "Service unavailable (DB or cache)"
 // @Failure      500  {object}  ErrorResponse  "Internal server error"
 // @Router       /notify [post]
 func (h *NotifyHandler) CreateNotification(ctx *wbgin.Context) {
 	var req NotificationRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		respondInvalid(ctx, err)
 		return
 	}
 
-	notif, err := app.NewNotification(req.Channel, req.Message, req.Recipient, req.SendAt)
+	key := ctx.GetHeader("Idempotency-Key")
+	if req.IdempotencyKey != "" {
+		if key != "" && key != req.IdempotencyKey {
+			ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "Idempotency-Key header and idempotency_key field differ"})
+			return
+		}
+		key = req.IdempotencyKey
+	}
+
+	// повтор отвечается сохраненным результатом до проверок запроса: контакт могли удалить,
+	// а send_at — уйти в прошлое, но клиент должен получить то же уведомление, что и в первый раз
+	if key != "" {
+		notif, err := h.replayIdempotent(ctx, key, req)
+		if err != nil {
+			respondSaveError(ctx, err)
+			return
+		}
+		if notif != nil {
+			h.respondCreated(ctx, notif)
+			return
+		}
+	}
+
+	contact, err := h.contact(req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		if errors.As(err, new(app.ValidationErrors)) {
+			respondInvalid(ctx, err)
+		} else {
+			ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		}
 		return
 	}
-	err = h.repo.SaveNotification(notif)
+	notif, err := req.newNotification(contact)
 	if err != nil {
-		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		respondInvalid(ctx, err)
 		return
 	}
-	err = h.cache.SaveNotification(notif)
+
+	if key == "" {
+		err = h.repo.SaveNotification(notif)
+	} else {
+		notif, err = h.saveIdempotent(ctx, notif, key, req)
+	}
 	if err != nil {
+		respondSaveError(ctx, err)
+		return
+	}
+	h.respondCreated(ctx, notif)
+}
+
+// respondCreated кэширует созданное (или повторно возвращаемое) уведомление и отвечает 201.
+func (h *NotifyHandler) respondCreated(ctx *wbgin.Context, notif *app.Notification) {
+	if err := h.cache.SaveNotification(notif); err != nil {
 		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
 		return
 	}
+	ctx.Header("ETag", etag(notif.Version))
 	ctx.JSON(http.StatusCreated, notif)
 }
 
+// respondSaveError переводит ошибку сохранения с ключом идемпотентности в HTTP-ответ.
+func respondSaveError(ctx *wbgin.Context, err error) {
+	switch {
+	case errors.Is(err, app.ErrInvalidIdempotencyKey):
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+	case errors.Is(err, app.ErrIdempotencyKeyReused):
+		ctx.JSON(http.StatusUnprocessableEntity, wbgin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+	}
+}
+
+// replayIdempotent ищет уведомление, уже созданное по ключу; nil, nil — ключ свободен
+// и запрос нужно обработать как новый.
+func (h *NotifyHandler) replayIdempotent(ctx *wbgin.Context, key string, req NotificationRequest) (*app.Notification, error) {
+	hash, err := req.hash()
+	if err != nil {
+		return nil, err
+	}
+	notif, err := h.repo.GetIdempotentNotification(key, hash)
+	if err != nil || notif == nil {
+		return nil, err
+	}
+	wbzlog.Logger.Info().Str("key", key).Str("id", notif.ID.String()).Msg("Replayed idempotent create request")
+	ctx.Header("Idempotent-Replayed", "true")
+	return notif, nil
+}
+
+// saveIdempotent сохраняет уведомление под ключом идемпотентности. Если ключ уже использован
+// тем же запросом, возвращает исходное уведомление и помечает ответ заголовком Idempotent-Replayed.
+func (h *NotifyHandler) saveIdempotent(ctx *wbgin.Context, notif *app.Notification, key string, req NotificationRequest) (*app.Notification, error) {
+	hash, err := req.hash()
+	if err != nil {
+		return nil, err
+	}
+	k, err := app.NewIdempotencyKey(key, hash, notif, h.idempotency.Retention)
+	if err != nil {
+		return nil, err
+	}
+	saved, created, err := h.repo.SaveNotificationIdempotent(notif, k)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		wbzlog.Logger.Info().Str("key", key).Str("id", saved.ID.String()).Msg("Replayed idempotent create request")
+		ctx.Header("Idempotent-Replayed", "true")
+	}
+	return saved, nil
+}
+
+// contact загружает контакт из contact_id запроса; nil, nil — уведомление адресовано напрямую.
+// Отсутствующий контакт — ошибка проверки поля contact_id (app.ValidationErrors), остальные
+// ошибки — недоступность хранилища.
+func (h *NotifyHandler) contact(req NotificationRequest) (*app.Contact, error) {
+	if req.ContactID == "" {
+		return nil, nil
+	}
+	contact, err := h.repo.GetContact(req.ContactID)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, app.ContactNotFound(req.ContactID)
+	}
+	return contact, nil
+}
+
+// RunIdempotencyPurge периодически удаляет просроченные ключи идемпотентности.
+func (h *NotifyHandler) RunIdempotencyPurge(ctx context.Context) {
+	ticker := time.NewTicker(h.idempotency.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wbzlog.Logger.Info().Msg("Graceful shutdown: stopping idempotency keys purge")
+			return
+		case <-ticker.C:
+			purged, err := h.repo.PurgeExpiredIdempotencyKeys()
+			if err != nil {
+				wbzlog.Logger.Error().Err(err).Msg("Failed to purge expired idempotency keys")
+				continue
+			}
+			if purged > 0 {
+				wbzlog.Logger.Debug().Int64("count", purged).Msg("Purged expired idempotency keys")
+			}
+		}
+	}
+}
+
+// Create Notifications Batch godoc
+// @Summary      Create Notifications Batch
+// @Description  Создает пакет уведомлений одной транзакцией. Каждый элемент проверяется отдельно:
+// @Description  невалидные элементы пропускаются и попадают в results с ошибкой, валидные сохраняются.
+// @Description  Уведомления пакета не кладутся в Redis сразу — кэш заполнится при первом чтении
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        notifications  body  []web.NotificationRequest  true  "Notifications to create (up to 10000)"
+// @Success      201  {object}  BatchResponse  "At least one notification created"
+// @Failure      400  {object}  ErrorResponse  "Body is not a JSON array or the batch is empty or too large"
+// @Failure      422  {object}  BatchResponse  "No valid notifications in the batch"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /notify/batch [post]
+func (h *NotifyHandler) CreateNotificationsBatch(ctx *wbgin.Context) {
+	var items []json.RawMessage
+	if err := ctx.ShouldBindJSON(&items); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if len(items) == 0 || len(items) > maxBatchSize {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": fmt.Sprintf("batch must contain from 1 to %d notifications", maxBatchSize)})
+		return
+	}
+
+	resp := BatchResponse{Results: make([]BatchItemResult, len(items))}
+	notifications := make([]*app.Notification, 0, len(items))
+	for i, item := range items {
+		resp.Results[i].Index = i
+
+		var req NotificationRequest
+		if err := json.Unmarshal(item, &req); err != nil {
+			resp.Results[i].reject(err)
+			continue
+		}
+		// ключ проверяется до валидации, как и в одиночном создании: повтор элемента с ключом
+		// должен получить понятный отказ, а не ошибку полей, которые с тех пор устарели
+		if req.IdempotencyKey != "" {
+			resp.Results[i].Error = "idempotency_key is not supported in batch requests"
+			continue
+		}
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			resp.Results[i].reject(err)
+			continue
+		}
+		contact, err := h.contact(req)
+		if err != nil && !errors.As(err, new(app.ValidationErrors)) {
+			ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			resp.Results[i].reject(err)
+			continue
+		}
+		notif, err := req.newNotification(contact)
+		if err != nil {
+			resp.Results[i].reject(err)
+			continue
+		}
+		resp.Results[i].ID = &notif.ID
+		notifications = append(notifications, notif)
+	}
+	resp.Created = len(notifications)
+	resp.Failed = len(items) - len(notifications)
+
+	if len(notifications) == 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+	if err := h.repo.SaveNotifications(notifications); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// List Notifications godoc
+// @Summary      List Notifications
+// @Description  Возвращает страницу уведомлений с фильтрами и сортировкой.
+// @Description  Для следующей страницы передайте next_cursor из ответа в параметр cursor, не меняя остальные параметры
+// @Tags         notifications
+// @Produce      json
+// @Param        status        query  string  false  "Statuses, comma-separated (pending,sent,...)"
+// @Param        channel       query  string  false  "Channel (email, telegram, sms, webhook, slack, webpush)"
+// @Param        recipient     query  string  false  "Exact recipient"
+// @Param        send_from     query  string  false  "send_at >= (RFC3339)"
+// @Param        send_to       query  string  false  "send_at < (RFC3339)"
+// @Param        created_from  query  string  false  "created_at >= (RFC3339)"
+// @Param        created_to    query  string  false  "created_at < (RFC3339)"
+// @Param        q             query  string  false  "Case-insensitive substring of the message"
+// @Param        sort          query  string  false  "Sort field: created_at (default), send_at, updated_at"
+// @Param        order         query  string  false  "Sort order: desc (default), asc"
+// @Param        limit         query  int     false  "Page size (default 50, max 500)"
+// @Param        cursor        query  string  false  "Cursor from the previous page"
+// @Success      200  {object}  app.NotificationPage  "Page of notifications"
+// @Failure      400  {object}  ErrorResponse  "Invalid filter, sort or cursor"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /notify [get]
+func (h *NotifyHandler) ListNotifications(ctx *wbgin.Context) {
+	var req NotificationListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	query, err := req.toQuery()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	notifications, err := h.repo.ListNotifications(query)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, app.NewNotificationPage(notifications, query.Limit, query.Sort))
+}
+
 // Get Notification godoc
 // @Summary      Get Notification
-// @Description  Получает уведомление по ID (из кэша или базы данных)
+// @Description  Получает уведомление по ID (из кэша или базы данных).
+// @Description  Параметр fields ограничивает набор полей ответа, например fields=status
 // @Tags         notifications
 // @Accept       json
 // @Produce      json
-// @Param        id   path   string  true  "Notification ID"
+// @Param        id      path   string  true   "Notification ID"
+// @Param        fields  query  string  false  "Comma-separated list of fields to return, e.g. status"
 // @Success      200  {object}  app.Notification  "Notification object"
-// @Failure      400  {object}  ErrorResponse  "Invalid notification ID"
+// @Failure      400  {object}  ErrorResponse  "Invalid notification ID or fields"
 // @Failure      404  {object}  ErrorResponse  "Notification not found"
 // @Failure      503  {object}  ErrorResponse  "Service unavailable"
 // @Failure      500  {object}  ErrorResponse  "Internal server error"
@@ -97,7 +394,7 @@ func (h *NotifyHandler) GetNotification(ctx *wbgin.Context) {
 		return
 	}
 	if notification == nil {
-		notification, err := h.repo.GetNotification(id)
+		notification, err = h.repo.GetNotification(id)
 		if err != nil {
 			ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
 			return
@@ -113,7 +410,191 @@ func (h *NotifyHandler) GetNotification(ctx *wbgin.Context) {
 				Msg("Failed to save notification to cache")
 		}
 	}
-	ctx.JSON(http.StatusOK, notification.Status)
+
+	ctx.Header("ETag", etag(notification.Version))
+
+	fields := ctx.Query("fields")
+	if fields == "" {
+		ctx.JSON(http.StatusOK, notification)
+		return
+	}
+	projection, err := selectFields(notification, strings.Split(fields, ","))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, projection)
+}
+
+// Get Delivery Attempts godoc
+// @Summary      Get Delivery Attempts
+// @Description  Возвращает историю попыток отправки уведомления по всем каналам, включая запасные, в хронологическом порядке.
+// @Description  Канал, через который уведомление доставлено, — в поле delivered_channel уведомления
+// @Tags         notifications
+// @Produce      json
+// @Param        id  path  string  true  "Notification ID"
+// @Success      200  {array}   app.DeliveryAttempt  "Delivery attempts"
+// @Failure      400  {object}  ErrorResponse  "Invalid notification ID"
+// @Failure      404  {object}  ErrorResponse  "Notification not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /notify/{id}/attempts [get]
+func (h *NotifyHandler) GetDeliveryAttempts(ctx *wbgin.Context) {
+	id := ctx.Param("id")
+
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return
+	}
+
+	notification, err := h.repo.GetNotification(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if notification == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+
+	attempts, err := h.repo.GetDeliveryAttempts(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, attempts)
+}
+
+// Update Notification godoc
+// @Summary      Update Notification
+// @Description  Изменяет время отправки, сообщение или получателя уведомления в статусе pending.
+// @Description  Требует текущую версию в заголовке If-Match (значение ETag) или в поле version
+// @Tags         notifications
+// @Accept       json
+// @Produce      json
+// @Param        id        path    string                         true   "Notification ID"
+// @Param        If-Match  header  string                         false  "Expected version (ETag)"
+// @Param        changes   body    web.NotificationUpdateRequest  true   "Fields to change"
+// @Success      200  {object}  app.Notification  "Updated notification"
+// @Failure      400  {object}  ErrorResponse  "Malformed JSON"
+// @Failure      404  {object}  ErrorResponse  "Notification not found"
+// @Failure      409  {object}  ErrorResponse  "Notification is not pending"
+// @Failure      412  {object}  ErrorResponse  "Version mismatch"
+// @Failure      422  {object}  ErrorResponse  "Field values failed validation"
+// @Failure      428  {object}  ErrorResponse  "Version is required"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /notify/{id} [patch]
+func (h *NotifyHandler) UpdateNotification(ctx *wbgin.Context) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return
+	}
+
+	var req NotificationUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(ctx.GetHeader("If-Match"))
+	if !ok && req.Version != nil {
+		expectedVersion, ok = *req.Version, true
+	}
+	if !ok {
+		ctx.JSON(http.StatusPreconditionRequired, wbgin.H{"error": "If-Match header or version field is required"})
+		return
+	}
+
+	notification, err := h.repo.GetNotification(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if notification == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+	if notification.Version != expectedVersion {
+		ctx.Header("ETag", etag(notification.Version))
+		ctx.JSON(http.StatusPreconditionFailed, wbgin.H{"error": "version mismatch"})
+		return
+	}
+
+	err = notification.ApplyPatch(app.NotificationPatch{Message: req.Message, Recipient: req.Recipient, SendAt: req.SendAt, Timezone: req.Timezone})
+	if err != nil {
+		if errors.Is(err, app.ErrStatusConflict) {
+			ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+			return
+		}
+		respondInvalid(ctx, err)
+		return
+	}
+
+	if err := h.repo.UpdateNotification(notification, expectedVersion); err != nil {
+		if errors.Is(err, app.ErrVersionConflict) {
+			ctx.JSON(http.StatusPreconditionFailed, wbgin.H{"error": "notification was modified concurrently"})
+			return
+		}
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err := h.cache.SaveNotification(notification); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notification.ID.String()).
+			Msg("Failed to save updated notification to cache")
+	}
+
+	ctx.Header("ETag", etag(notification.Version))
+	ctx.JSON(http.StatusOK, notification)
+}
+
+// Cancel Notification godoc
+// @Summary      Cancel Notification
+// @Description  Отменяет уведомление, если оно еще не передано на отправку (pending, processing, queued). История сохраняется
+// @Tags         notifications
+// @Produce      json
+// @Param        id   path   string  true  "Notification ID"
+// @Success      200  {object}  app.Notification  "Canceled notification"
+// @Failure      400  {object}  ErrorResponse  "Invalid notification ID"
+// @Failure      404  {object}  ErrorResponse  "Notification not found"
+// @Failure      409  {object}  ErrorResponse  "Notification is already being sent or finished"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /notify/{id}/cancel [post]
+func (h *NotifyHandler) CancelNotification(ctx *wbgin.Context) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return
+	}
+
+	err := h.repo.TransitionNotification(id, app.Canceled, app.Pending, app.Processing, app.Queued)
+	if err != nil && !errors.Is(err, app.ErrStatusConflict) {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	notification, lookupErr := h.repo.GetNotification(id)
+	if lookupErr != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": lookupErr.Error()})
+		return
+	}
+	if notification == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusConflict, wbgin.H{"error": "notification can no longer be canceled, status: " + string(notification.Status)})
+		return
+	}
+
+	if err := h.cache.SaveNotification(notification); err != nil {
+		wbzlog.Logger.Error().
+			Err(err).
+			Str("id", notification.ID.String()).
+			Msg("Failed to save canceled notification to cache")
+	}
+	ctx.JSON(http.StatusOK, notification)
 }
 
 // Delete Notification godoc
@@ -146,3 +627,67 @@ func (h *NotifyHandler) DeleteNotification(ctx *wbgin.Context) {
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// etag формирует ETag из версии уведомления.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch достает версию из заголовка If-Match ("3" или W/"3").
+func parseIfMatch(header string) (int, bool) {
+	value := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	value = strings.Trim(value, `"`)
+	if value == "" {
+		return 0, false
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// selectFields оставляет в JSON-представлении значения только перечисленные поля.
+func selectFields(value any, fields []string) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, ok := all[field]
+		if !ok {
+			// поле с omitempty может просто отсутствовать в текущем значении
+			if !knownField(value, field) {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+			v = json.RawMessage("null")
+		}
+		selected[field] = v
+	}
+	return selected, nil
+}
+
+// knownField проверяет, что у типа есть поле с таким json-именем.
+func knownField(value any, field string) bool {
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == field {
+			return true
+		}
+	}
+	return false
+}
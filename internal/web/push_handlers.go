@@ -0,0 +1,145 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	"fmt"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+)
+
+type PushHandler struct {
+	repo      PushStorage
+	vapid     VAPIDKeyProvider
+	endpoints PushEndpointValidator
+}
+
+type PushStorage interface {
+	SavePushSubscription(subscription *app.PushSubscription) error
+	GetPushSubscriptions(recipient string) ([]*app.PushSubscription, error)
+	DeletePushSubscription(id string) (bool, error)
+}
+
+// VAPIDKeyProvider отдает открытый ключ VAPID; false — канал webpush не настроен.
+type VAPIDKeyProvider interface {
+	VAPIDPublicKey() (string, bool)
+}
+
+// PushEndpointValidator проверяет, что на endpoint подписки можно отправлять запросы
+// (он не указывает на внутренние адреса).
+type PushEndpointValidator interface {
+	ValidPushEndpoint(endpoint string) error
+}
+
+func NewPushHandler(repo PushStorage, vapid VAPIDKeyProvider, endpoints PushEndpointValidator) *PushHandler {
+	return &PushHandler{repo: repo, vapid: vapid, endpoints: endpoints}
+}
+
+// Subscribe godoc
+// @Summary      Register Push Subscription
+// @Description  Сохраняет подписку браузера на Web Push для получателя канала webpush.
+// @Description  Повторная регистрация того же endpoint обновляет ключи и получателя
+// @Tags         push
+// @Accept       json
+// @Produce      json
+// @Param        subscription  body  web.PushSubscriptionRequest  true  "Push subscription"
+// @Success      201  {object}  app.PushSubscription  "Saved subscription"
+// @Failure      400  {object}  ErrorResponse  "Invalid subscription"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /push/subscriptions [post]
+func (h *PushHandler) Subscribe(ctx *wbgin.Context) {
+	var req PushSubscriptionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := app.NewPushSubscription(req.Recipient, req.Endpoint, req.Keys.P256dh, req.Keys.Auth, ctx.Request.UserAgent())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err := h.endpoints.ValidPushEndpoint(subscription.Endpoint); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": fmt.Sprintf("%v: endpoint: %v", app.ErrInvalidPushSubscription, err)})
+		return
+	}
+	if err := h.repo.SavePushSubscription(subscription); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, subscription)
+}
+
+// List Push Subscriptions godoc
+// @Summary      List Push Subscriptions
+// @Description  Возвращает push-подписки получателя
+// @Tags         push
+// @Produce      json
+// @Param        recipient  query  string  true  "Recipient"
+// @Success      200  {array}   app.PushSubscription  "Subscriptions"
+// @Failure      400  {object}  ErrorResponse  "Recipient is required"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /push/subscriptions [get]
+func (h *PushHandler) ListSubscriptions(ctx *wbgin.Context) {
+	recipient := ctx.Query("recipient")
+	if recipient == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "recipient is required"})
+		return
+	}
+
+	subscriptions, err := h.repo.GetPushSubscriptions(recipient)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if subscriptions == nil {
+		subscriptions = []*app.PushSubscription{}
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+// Unsubscribe godoc
+// @Summary      Delete Push Subscription
+// @Description  Удаляет push-подписку (например, после pushSubscription.unsubscribe() в браузере)
+// @Tags         push
+// @Produce      json
+// @Param        id   path   string  true  "Subscription ID"
+// @Success      204  {string}  string  "Subscription deleted"
+// @Failure      400  {object}  ErrorResponse  "Invalid subscription ID"
+// @Failure      404  {object}  ErrorResponse  "Subscription not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /push/subscriptions/{id} [delete]
+func (h *PushHandler) Unsubscribe(ctx *wbgin.Context) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return
+	}
+
+	deleted, err := h.repo.DeletePushSubscription(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// VAPID Public Key godoc
+// @Summary      Get VAPID Public Key
+// @Description  Возвращает открытый ключ VAPID, который браузер передает в pushManager.subscribe()
+// @Tags         push
+// @Produce      json
+// @Success      200  {object}  VAPIDPublicKeyResponse  "VAPID public key"
+// @Failure      404  {object}  ErrorResponse  "Web Push is not configured"
+// @Router       /push/vapid-public-key [get]
+func (h *PushHandler) VAPIDPublicKey(ctx *wbgin.Context) {
+	key, ok := h.vapid.VAPIDPublicKey()
+	if !ok {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "web push is not configured"})
+		return
+	}
+	ctx.JSON(http.StatusOK, VAPIDPublicKeyResponse{PublicKey: key})
+}
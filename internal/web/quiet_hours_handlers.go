@@ -0,0 +1,108 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+)
+
+type QuietHoursHandler struct {
+	repo QuietHoursStorage
+}
+
+type QuietHoursStorage interface {
+	SaveQuietHours(quietHours *app.QuietHours) error
+	ListQuietHours(recipient string) ([]*app.QuietHours, error)
+	DeleteQuietHours(recipient string, channel app.ChannelType) (bool, error)
+}
+
+func NewQuietHoursHandler(repo QuietHoursStorage) *QuietHoursHandler {
+	return &QuietHoursHandler{repo: repo}
+}
+
+// Set Quiet Hours godoc
+// @Summary      Set Quiet Hours
+// @Description  Задает тихие часы получателя (например, 22:00–08:00 в его часовом поясе).
+// @Description  Уведомление, пришедшее на отправку в тихие часы, откладывается до их окончания без траты попытки
+// @Tags         quiet-hours
+// @Accept       json
+// @Produce      json
+// @Param        quiet_hours  body  web.QuietHoursRequest  true  "Quiet hours"
+// @Success      200  {object}  app.QuietHours  "Saved quiet hours"
+// @Failure      400  {object}  ErrorResponse  "Invalid time or timezone"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /quiet-hours [put]
+func (h *QuietHoursHandler) SetQuietHours(ctx *wbgin.Context) {
+	var req QuietHoursRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+
+	quietHours, err := app.NewQuietHours(req.Recipient, req.Channel, req.Timezone, req.Start, req.End)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err := h.repo.SaveQuietHours(quietHours); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, quietHours)
+}
+
+// List Quiet Hours godoc
+// @Summary      List Quiet Hours
+// @Description  Возвращает тихие часы получателя по всем каналам
+// @Tags         quiet-hours
+// @Produce      json
+// @Param        recipient  query  string  true  "Recipient"
+// @Success      200  {array}   app.QuietHours  "Quiet hours"
+// @Failure      400  {object}  ErrorResponse  "Recipient is required"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /quiet-hours [get]
+func (h *QuietHoursHandler) ListQuietHours(ctx *wbgin.Context) {
+	recipient := ctx.Query("recipient")
+	if recipient == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "recipient is required"})
+		return
+	}
+
+	quietHours, err := h.repo.ListQuietHours(recipient)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, quietHours)
+}
+
+// Delete Quiet Hours godoc
+// @Summary      Delete Quiet Hours
+// @Description  Удаляет тихие часы получателя для канала (без channel — общие для всех каналов)
+// @Tags         quiet-hours
+// @Produce      json
+// @Param        recipient  query  string  true   "Recipient"
+// @Param        channel    query  string  false  "Channel"
+// @Success      204  {string}  string  "Quiet hours deleted"
+// @Failure      400  {object}  ErrorResponse  "Recipient is required"
+// @Failure      404  {object}  ErrorResponse  "Quiet hours not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /quiet-hours [delete]
+func (h *QuietHoursHandler) DeleteQuietHours(ctx *wbgin.Context) {
+	recipient := ctx.Query("recipient")
+	if recipient == "" {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "recipient is required"})
+		return
+	}
+
+	deleted, err := h.repo.DeleteQuietHours(recipient, app.ChannelType(ctx.Query("channel")))
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if !deleted {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "quiet hours not found"})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -6,14 +6,49 @@ import (
 	wbgin "github.com/wb-go/wbf/ginext"
 )
 
-func RegisterRoutes(engine *wbgin.Engine, handler *NotifyHandler) {
+func RegisterRoutes(engine *wbgin.Engine, handler *NotifyHandler, deadLetters *DeadLetterHandler, schedules *ScheduleHandler, quietHours *QuietHoursHandler, push *PushHandler, telegram *TelegramHandler, contacts *ContactHandler) {
 	api := engine.Group("")
 	{
 		api.POST("/notify", handler.CreateNotification)
+		api.GET("/notify", handler.ListNotifications)
+		api.POST("/notify/batch", handler.CreateNotificationsBatch)
 		api.GET("/notify/:id", handler.GetNotification)
+		api.GET("/notify/:id/attempts", handler.GetDeliveryAttempts)
+		api.PATCH("/notify/:id", handler.UpdateNotification)
 		api.DELETE("/notify/:id", handler.DeleteNotification)
+		api.POST("/notify/:id/cancel", handler.CancelNotification)
+
+		api.POST("/schedules", schedules.CreateSchedule)
+		api.GET("/schedules", schedules.ListSchedules)
+		api.GET("/schedules/:id", schedules.GetSchedule)
+		api.DELETE("/schedules/:id", schedules.DeleteSchedule)
+		api.POST("/schedules/:id/pause", schedules.PauseSchedule)
+		api.POST("/schedules/:id/resume", schedules.ResumeSchedule)
+
+		api.PUT("/quiet-hours", quietHours.SetQuietHours)
+		api.GET("/quiet-hours", quietHours.ListQuietHours)
+		api.DELETE("/quiet-hours", quietHours.DeleteQuietHours)
+
+		api.POST("/push/subscriptions", push.Subscribe)
+		api.GET("/push/subscriptions", push.ListSubscriptions)
+		api.DELETE("/push/subscriptions/:id", push.Unsubscribe)
+		api.GET("/push/vapid-public-key", push.VAPIDPublicKey)
+
+		api.GET("/telegram/subscribers/:username", telegram.GetSubscriber)
+
+		api.POST("/contacts", contacts.CreateContact)
+		api.GET("/contacts", contacts.ListContacts)
+		api.GET("/contacts/:id", contacts.GetContact)
+		api.PUT("/contacts/:id", contacts.UpdateContact)
+		api.DELETE("/contacts/:id", contacts.DeleteContact)
+
+		api.GET("/dead-letters", deadLetters.ListDeadLetters)
+		api.GET("/dead-letters/:id", deadLetters.GetDeadLetter)
+		api.POST("/dead-letters/:id/replay", deadLetters.ReplayDeadLetter)
 		api.GET("/swagger/*any", func(c *wbgin.Context) {
 			httpSwagger.WrapHandler(c.Writer, c.Request)
 		})
+		// веб-интерфейс; Push API требует страницу и service worker с http(s)-адреса
+		api.Static("/ui", "./web")
 	}
 }
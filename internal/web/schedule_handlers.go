@@ -0,0 +1,206 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	"errors"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"strconv"
+)
+
+type ScheduleHandler struct {
+	repo ScheduleStorage
+}
+
+type ScheduleStorage interface {
+	SaveSchedule(schedule *app.Schedule) error
+	GetSchedules(limit, offset int) ([]*app.Schedule, error)
+	GetSchedule(id string) (*app.Schedule, error)
+	UpdateScheduleStatus(schedule *app.Schedule, expected app.ScheduleStatus) error
+	DeleteSchedule(id string) error
+}
+
+func NewScheduleHandler(repo ScheduleStorage) *ScheduleHandler {
+	return &ScheduleHandler{repo: repo}
+}
+
+// Create Schedule godoc
+// @Summary      Create Schedule
+// @Description  Создает расписание повторяющихся уведомлений (cron или RRULE с часовым поясом).
+// @Description  Продюсер заранее создает из него обычные уведомления на каждое срабатывание
+// @Tags         schedules
+// @Accept       json
+// @Produce      json
+// @Param        schedule  body  web.ScheduleRequest  true  "Schedule to create"
+// @Success      201  {object}  app.Schedule  "Created schedule"
+// @Failure      400  {object}  ErrorResponse  "Malformed JSON or missing required fields"
+// @Failure      422  {object}  ErrorResponse  "Invalid expression, timezone or bounds"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules [post]
+func (h *ScheduleHandler) CreateSchedule(ctx *wbgin.Context) {
+	var req ScheduleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+
+	schedule, err := app.NewSchedule(req.Channel, req.Message, req.Recipient, app.ScheduleKind(req.Kind), req.Expression, req.Timezone, req.StartAt, req.EndAt, req.MaxOccurrences)
+	if err != nil {
+		respondInvalid(ctx, err)
+		return
+	}
+	if err := h.repo.SaveSchedule(schedule); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, schedule)
+}
+
+// List Schedules godoc
+// @Summary      List Schedules
+// @Description  Возвращает расписания, новые первыми
+// @Tags         schedules
+// @Produce      json
+// @Param        limit   query  int  false  "Page size (default 50, max 500)"
+// @Param        offset  query  int  false  "Offset"
+// @Success      200  {array}   app.Schedule  "Schedules"
+// @Failure      400  {object}  ErrorResponse  "Invalid pagination"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules [get]
+func (h *ScheduleHandler) ListSchedules(ctx *wbgin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultPageSize)))
+	if err != nil || limit < 1 || limit > maxPageSize {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "limit is invalid"})
+		return
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "offset is invalid"})
+		return
+	}
+
+	schedules, err := h.repo.GetSchedules(limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+// Get Schedule godoc
+// @Summary      Get Schedule
+// @Description  Возвращает расписание по ID
+// @Tags         schedules
+// @Produce      json
+// @Param        id   path   string  true  "Schedule ID"
+// @Success      200  {object}  app.Schedule  "Schedule"
+// @Failure      400  {object}  ErrorResponse  "Invalid schedule ID"
+// @Failure      404  {object}  ErrorResponse  "Schedule not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules/{id} [get]
+func (h *ScheduleHandler) GetSchedule(ctx *wbgin.Context) {
+	schedule, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+// Pause Schedule godoc
+// @Summary      Pause Schedule
+// @Description  Приостанавливает расписание и отменяет уже созданные, но еще не отправленные уведомления
+// @Tags         schedules
+// @Produce      json
+// @Param        id   path   string  true  "Schedule ID"
+// @Success      200  {object}  app.Schedule  "Paused schedule"
+// @Failure      400  {object}  ErrorResponse  "Invalid schedule ID"
+// @Failure      404  {object}  ErrorResponse  "Schedule not found"
+// @Failure      409  {object}  ErrorResponse  "Schedule is not active"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules/{id}/pause [post]
+func (h *ScheduleHandler) PauseSchedule(ctx *wbgin.Context) {
+	h.changeStatus(ctx, (*app.Schedule).Pause)
+}
+
+// Resume Schedule godoc
+// @Summary      Resume Schedule
+// @Description  Возобновляет расписание с ближайшего будущего срабатывания; пропущенные за паузу срабатывания не отправляются
+// @Tags         schedules
+// @Produce      json
+// @Param        id   path   string  true  "Schedule ID"
+// @Success      200  {object}  app.Schedule  "Resumed schedule"
+// @Failure      400  {object}  ErrorResponse  "Invalid schedule ID"
+// @Failure      404  {object}  ErrorResponse  "Schedule not found"
+// @Failure      409  {object}  ErrorResponse  "Schedule is not paused"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules/{id}/resume [post]
+func (h *ScheduleHandler) ResumeSchedule(ctx *wbgin.Context) {
+	h.changeStatus(ctx, (*app.Schedule).Resume)
+}
+
+// Delete Schedule godoc
+// @Summary      Delete Schedule
+// @Description  Удаляет расписание и отменяет созданные им уведомления, которые еще не отправлены
+// @Tags         schedules
+// @Produce      json
+// @Param        id   path   string  true  "Schedule ID"
+// @Success      204  {string}  string  "Schedule deleted"
+// @Failure      400  {object}  ErrorResponse  "Invalid schedule ID"
+// @Failure      404  {object}  ErrorResponse  "Schedule not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /schedules/{id} [delete]
+func (h *ScheduleHandler) DeleteSchedule(ctx *wbgin.Context) {
+	schedule, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteSchedule(schedule.ID.String()); err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *ScheduleHandler) changeStatus(ctx *wbgin.Context, change func(*app.Schedule) error) {
+	schedule, ok := h.lookup(ctx)
+	if !ok {
+		return
+	}
+	expected := schedule.Status
+	if err := change(schedule); err != nil {
+		if errors.Is(err, app.ErrScheduleConflict) {
+			ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusUnprocessableEntity, wbgin.H{"error": err.Error()})
+		return
+	}
+	if err := h.repo.UpdateScheduleStatus(schedule, expected); err != nil {
+		if errors.Is(err, app.ErrScheduleConflict) {
+			ctx.JSON(http.StatusConflict, wbgin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) lookup(ctx *wbgin.Context) (*app.Schedule, bool) {
+	id := ctx.Param("id")
+	if !app.IsValidUUID(id) {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "id is invalid"})
+		return nil, false
+	}
+
+	schedule, err := h.repo.GetSchedule(id)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return nil, false
+	}
+	if schedule == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "id not found"})
+		return nil, false
+	}
+	return schedule, true
+}
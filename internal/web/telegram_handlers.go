@@ -0,0 +1,51 @@
+package web
+
+import (
+	"delayedNotifier/internal/app"
+	wbgin "github.com/wb-go/wbf/ginext"
+	"net/http"
+	"strings"
+)
+
+type TelegramHandler struct {
+	repo TelegramSubscriberStorage
+}
+
+type TelegramSubscriberStorage interface {
+	GetTelegramSubscriberByUsername(username string) (*app.TelegramSubscriber, error)
+}
+
+func NewTelegramHandler(repo TelegramSubscriberStorage) *TelegramHandler {
+	return &TelegramHandler{repo: repo}
+}
+
+// Get Telegram Subscriber godoc
+// @Summary      Get Telegram Subscriber
+// @Description  Ищет подписчика бота по username (с @ или без, регистр не важен) и возвращает его chat_id.
+// @Description  Подписчик появляется после команды /start боту и удаляется после /stop или блокировки бота
+// @Tags         telegram
+// @Produce      json
+// @Param        username  path  string  true  "Telegram username"
+// @Success      200  {object}  app.TelegramSubscriber  "Subscriber"
+// @Failure      400  {object}  ErrorResponse  "Invalid username"
+// @Failure      404  {object}  ErrorResponse  "Subscriber not found"
+// @Failure      503  {object}  ErrorResponse  "Service unavailable"
+// @Router       /telegram/subscribers/{username} [get]
+func (h *TelegramHandler) GetSubscriber(ctx *wbgin.Context) {
+	username, ok := app.ParseTelegramUsername("@" + strings.TrimPrefix(ctx.Param("username"), "@"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, wbgin.H{"error": "username is invalid"})
+		return
+	}
+
+	subscriber, err := h.repo.GetTelegramSubscriberByUsername(username)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, wbgin.H{"error": err.Error()})
+		return
+	}
+	if subscriber == nil {
+		ctx.JSON(http.StatusNotFound, wbgin.H{"error": "subscriber not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriber)
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id              UUID PRIMARY KEY,
+    notification_id UUID,
+    reason          TEXT NOT NULL,
+    body            TEXT NOT NULL,
+    death_count     BIGINT NOT NULL DEFAULT 0,
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    replayed_at     TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dead_letters_created_at ON dead_letters (created_at DESC);
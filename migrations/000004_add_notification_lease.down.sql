@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_notifications_lease;
+DROP INDEX IF EXISTS idx_notifications_due;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS lease_until;
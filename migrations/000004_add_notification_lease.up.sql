@@ -0,0 +1,10 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS lease_until TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_due
+    ON notifications ((COALESCE(next_attempt_at, send_at)))
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_lease
+    ON notifications (lease_until)
+    WHERE status = 'processing';
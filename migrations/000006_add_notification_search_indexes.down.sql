@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_message_trgm;
+DROP INDEX IF EXISTS idx_notifications_recipient;
+DROP INDEX IF EXISTS idx_notifications_status_created_at;
+DROP INDEX IF EXISTS idx_notifications_updated_at_id;
+DROP INDEX IF EXISTS idx_notifications_send_at_id;
+DROP INDEX IF EXISTS idx_notifications_created_at_id;
@@ -0,0 +1,22 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- keyset-пагинация: (поле сортировки, id) в обоих направлениях
+CREATE INDEX IF NOT EXISTS idx_notifications_created_at_id
+    ON notifications (created_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_send_at_id
+    ON notifications (send_at, id);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_updated_at_id
+    ON notifications (updated_at, id);
+
+-- фильтры
+CREATE INDEX IF NOT EXISTS idx_notifications_status_created_at
+    ON notifications (status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_recipient
+    ON notifications (recipient);
+
+-- поиск подстроки в тексте сообщения (ILIKE '%...%')
+CREATE INDEX IF NOT EXISTS idx_notifications_message_trgm
+    ON notifications USING gin (message gin_trgm_ops);
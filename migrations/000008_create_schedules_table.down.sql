@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_schedule_occurrence;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS schedules;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS schedules (
+    id              UUID PRIMARY KEY,
+    channel         TEXT NOT NULL,
+    recipient       TEXT NOT NULL,
+    message         TEXT NOT NULL,
+    kind            TEXT NOT NULL, -- cron, rrule
+    expression      TEXT NOT NULL,
+    timezone        TEXT NOT NULL DEFAULT 'UTC',
+    start_at        TIMESTAMPTZ NOT NULL,
+    end_at          TIMESTAMPTZ,
+    max_occurrences INT NOT NULL DEFAULT 0,
+    occurrences     INT NOT NULL DEFAULT 0,
+    next_run_at     TIMESTAMPTZ,
+    status          TEXT NOT NULL, -- active, paused, completed
+    created_at      TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_next_run
+    ON schedules (next_run_at)
+    WHERE status = 'active';
+
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS schedule_id UUID REFERENCES schedules (id) ON DELETE SET NULL;
+
+-- одно срабатывание расписания — одно уведомление, даже если материализация повторилась
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_schedule_occurrence
+    ON notifications (schedule_id, send_at)
+    WHERE schedule_id IS NOT NULL;
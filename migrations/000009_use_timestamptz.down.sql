@@ -0,0 +1,18 @@
+ALTER TABLE schedules
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE idempotency_keys
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN expires_at TYPE TIMESTAMP;
+
+ALTER TABLE dead_letters
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN replayed_at TYPE TIMESTAMP;
+
+ALTER TABLE notifications
+    ALTER COLUMN send_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP,
+    ALTER COLUMN next_attempt_at TYPE TIMESTAMP,
+    ALTER COLUMN lease_until TYPE TIMESTAMP;
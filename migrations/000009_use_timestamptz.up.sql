@@ -0,0 +1,19 @@
+-- существующие значения трактуются в часовом поясе сессии (параметр TimeZone сервера)
+ALTER TABLE notifications
+    ALTER COLUMN send_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN next_attempt_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN lease_until TYPE TIMESTAMPTZ;
+
+ALTER TABLE dead_letters
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN replayed_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE idempotency_keys
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE schedules
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS quiet_hours (
+    recipient   TEXT NOT NULL,
+    channel     TEXT NOT NULL DEFAULT '', -- пустая строка — все каналы
+    timezone    TEXT NOT NULL DEFAULT 'UTC',
+    start_time  TEXT NOT NULL, -- ЧЧ:ММ
+    end_time    TEXT NOT NULL, -- ЧЧ:ММ
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (recipient, channel)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS push_subscriptions (
+    id          UUID PRIMARY KEY,
+    recipient   TEXT NOT NULL,
+    endpoint    TEXT NOT NULL UNIQUE,
+    p256dh      TEXT NOT NULL,
+    auth        TEXT NOT NULL,
+    user_agent  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_subscriptions_recipient ON push_subscriptions (recipient);
@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS attachments,
+    DROP COLUMN IF EXISTS html,
+    DROP COLUMN IF EXISTS subject;
@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS subject TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS html TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS attachments JSONB NOT NULL DEFAULT '[]'; -- [{filename, content_type, content (base64)}]
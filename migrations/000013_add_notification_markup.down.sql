@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS silent,
+    DROP COLUMN IF EXISTS buttons,
+    DROP COLUMN IF EXISTS parse_mode;
@@ -0,0 +1,4 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS parse_mode TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS buttons JSONB NOT NULL DEFAULT '[]', -- ряды кнопок [[{text, url}]]
+    ADD COLUMN IF NOT EXISTS silent BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS telegram_subscribers (
+    chat_id        BIGINT PRIMARY KEY,
+    username       TEXT,
+    first_name     TEXT NOT NULL DEFAULT '',
+    subscribed_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- username в Telegram регистронезависим и может перейти к другому чату: при поиске берется
+-- последний обновленный подписчик, поэтому индекс не уникальный
+CREATE INDEX IF NOT EXISTS idx_telegram_subscribers_username ON telegram_subscribers (lower(username));
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_notifications_contact_id;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS contact_id;
+
+DROP TABLE IF EXISTS contacts;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS contacts (
+    id                UUID PRIMARY KEY,
+    name              TEXT NOT NULL,
+    email             TEXT NOT NULL DEFAULT '',
+    telegram_chat_id  TEXT NOT NULL DEFAULT '',
+    phone             TEXT NOT NULL DEFAULT '',
+    channels          TEXT[] NOT NULL DEFAULT '{}', -- каналы в порядке предпочтения
+    locale            TEXT NOT NULL DEFAULT '',
+    timezone          TEXT NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- после удаления контакта уведомление уходит по адресу, выбранному последним
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS contact_id UUID REFERENCES contacts (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_contact_id
+    ON notifications (contact_id)
+    WHERE contact_id IS NOT NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS delivery_attempts;
+
+ALTER TABLE notifications
+    DROP COLUMN IF EXISTS delivered_channel,
+    DROP COLUMN IF EXISTS primary_destination,
+    DROP COLUMN IF EXISTS fallback_step,
+    DROP COLUMN IF EXISTS fallbacks;
@@ -0,0 +1,20 @@
+ALTER TABLE notifications
+    ADD COLUMN IF NOT EXISTS fallbacks         JSONB NOT NULL DEFAULT '[]', -- запасные каналы [{channel, recipient}] по порядку
+    ADD COLUMN IF NOT EXISTS fallback_step     INT NOT NULL DEFAULT 0,     -- сколько запасных каналов задействовано
+    ADD COLUMN IF NOT EXISTS primary_destination JSONB,                   -- основной {channel, recipient}, пока идет запасной
+    ADD COLUMN IF NOT EXISTS delivered_channel TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id               UUID PRIMARY KEY,
+    notification_id  UUID NOT NULL REFERENCES notifications (id) ON DELETE CASCADE,
+    channel          TEXT NOT NULL,
+    recipient        TEXT NOT NULL,
+    attempt          INT NOT NULL,
+    fallback_step    INT NOT NULL DEFAULT 0,
+    outcome          TEXT NOT NULL, -- sent или failed
+    error            TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_notification_id
+    ON delivery_attempts (notification_id, created_at);
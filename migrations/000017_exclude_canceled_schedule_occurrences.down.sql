@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_notifications_schedule_occurrence;
+
+-- отмененные повторы срабатываний не дали бы восстановить прежний индекс
+DELETE FROM notifications n
+WHERE n.schedule_id IS NOT NULL
+  AND n.status = 'canceled'
+  AND EXISTS (
+      SELECT 1 FROM notifications o
+      WHERE o.schedule_id = n.schedule_id AND o.send_at = n.send_at AND o.id <> n.id AND o.status <> 'canceled'
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_schedule_occurrence
+    ON notifications (schedule_id, send_at)
+    WHERE schedule_id IS NOT NULL;
@@ -0,0 +1,6 @@
+-- отмененное при паузе срабатывание не должно мешать создать его заново после возобновления
+DROP INDEX IF EXISTS idx_notifications_schedule_occurrence;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notifications_schedule_occurrence
+    ON notifications (schedule_id, send_at)
+    WHERE schedule_id IS NOT NULL AND status <> 'canceled';
@@ -0,0 +1,16 @@
+// Service worker для канала webpush: показывает расшифрованное браузером сообщение
+// ({"id", "title", "body"}) системным уведомлением.
+self.addEventListener('push', (event) => {
+  const data = event.data ? event.data.json() : {};
+  event.waitUntil(
+    self.registration.showNotification(data.title || 'DelayedNotifier', {
+      body: data.body || '',
+      tag: data.id
+    })
+  );
+});
+
+self.addEventListener('notificationclick', (event) => {
+  event.notification.close();
+  event.waitUntil(clients.openWindow(self.registration.scope));
+});